@@ -53,6 +53,13 @@ func main() {
 	}
 	definition.ClientSet = clientset
 
+	// 初始化监控数据源
+	if err := utils.InitMetricsSource(); err != nil {
+		fmt.Printf("初始化监控数据源失败: %v\n", err)
+		return
+	}
+	fmt.Println("监控数据源:", utils.Metrics.Name())
+
 	// 创建调度器实例
 	scheduler, err := pkg.NewCustomScheduler(definition.SchedulerName)
 	if err != nil {
@@ -67,7 +74,11 @@ func main() {
 	}
 
 	// 启动监控goroutine
+	go utils.Cache.RunRefresher(definition.MetricsRefreshInterval)
 	go monitorClusterResources()
+	go profileWorkloads(scheduler)
+	go checkNodeHealth(scheduler)
+	go refreshNodeImages(scheduler)
 	go printMetrics()
 
 	fmt.Println("---->自定义调度器启动<---->")
@@ -88,23 +99,23 @@ func initKubernetesClient(kubeconfig string) (*kubernetes.Clientset, error) {
 // initNodeInfo 初始化节点信息
 func initNodeInfo() error {
 	readyNodes := make(map[string]string)
-	nodesNames, err := utils.K8sNodesAvailableNames(true)
+	nodes, err := utils.K8sTierNodesAvailable()
 	if err != nil {
 		return fmt.Errorf("获取节点名称错误: %v", err)
 	}
 
-	for _, n := range nodesNames {
-		ip := utils.GetNodeIPByName(n)
-		readyNodes[n] = ip
+	for _, n := range nodes {
+		ip := utils.GetNodeIPByName(n.Name)
+		readyNodes[n.Name] = fmt.Sprintf("%s（%s层）", ip, utils.NodeTier(n.Name))
 	}
 	fmt.Println("可用节点:", readyNodes)
 
 	// 获取基础资源占用
-	cpuMonitor, err := utils.HttpGetNodeMonitor("cpu")
+	cpuMonitor, err := utils.GetNodeMonitor("cpu")
 	if err != nil {
 		return fmt.Errorf("获取CPU监控数据错误: %v", err)
 	}
-	memMonitor, err := utils.HttpGetNodeMonitor("mem")
+	memMonitor, err := utils.GetNodeMonitor("mem")
 	if err != nil {
 		return fmt.Errorf("获取Mem监控数据错误: %v", err)
 	}
@@ -113,8 +124,11 @@ func initNodeInfo() error {
 	definition.BasicOccupationMem = memMonitor
 
 	fmt.Println("集群初始资源占用:")
-	_ = utils.PrintNodeMonitorToRead("cpu")
-	_ = utils.PrintNodeMonitorToRead("mem")
+	for _, req := range []string{"cpu", "mem"} {
+		if err := utils.PrintNodeMonitorToRead(req); err != nil {
+			return fmt.Errorf("打印 %s 监控数据错误: %v", req, err)
+		}
+	}
 
 	return nil
 }
@@ -146,6 +160,8 @@ func watchK8sEvents(scheduler *pkg.CustomScheduler) {
 		eventType := event.Type
 		podName := pod.ObjectMeta.Name
 		podNamespace := pod.ObjectMeta.Namespace
+		// 记录 Pod 所属的工作负载，Pod 结束或删除后其历史用量仍计入画像
+		scheduler.Profiler.Observe(pod)
 
 		fmt.Println("----> 监听到 Pod:", podName, "事件:", eventType, "<----")
 
@@ -162,12 +178,20 @@ func watchK8sEvents(scheduler *pkg.CustomScheduler) {
 				fmt.Printf("警告: 调度队列已满, Pod %s 无法加入\n", podName)
 			}
 
-		case pod.Status.Phase == corev1.PodFailed && (pod.Status.Reason == "OutOfmemory" || pod.Status.Reason == "OutOfcpu"):
+		case eventType != watchapi.Deleted && pod.Status.Phase == corev1.PodFailed &&
+			(pod.Status.Reason == "OutOfmemory" || pod.Status.Reason == "OutOfcpu"):
+			// 被 kubelet 拒绝的 Pod 不占用节点资源，先移出请求量统计再删除
 			fmt.Printf("检测到Pod %s/%s 资源不足, 将删除\n", podNamespace, podName)
+			scheduler.UpdateNodePods(pod)
 			go deletePodWithRetry(scheduler, pod, 3, 2*time.Second)
 
-		case eventType == watchapi.Deleted && pod.Spec.SchedulerName == scheduler.SchedulerName:
+		case eventType == watchapi.Deleted || utils.IsPodTerminated(pod):
+			// 已删除或已结束的 Pod 不再占用节点资源
 			scheduler.UpdateNodePods(pod)
+
+		case pod.Spec.NodeName != "":
+			// 记录所有已绑定的 Pod，用于 Allocatable 请求量统计
+			scheduler.TrackPod(pod)
 		}
 	}
 }
@@ -200,6 +224,47 @@ func monitorClusterResources() {
 	}
 }
 
+// profileWorkloads 定期刷新工作负载用量画像
+func profileWorkloads(scheduler *pkg.CustomScheduler) {
+	ticker := time.NewTicker(definition.ProfileInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := scheduler.Profiler.Refresh(); err != nil {
+			fmt.Printf("刷新工作负载画像错误: %v\n", err)
+		}
+		<-ticker.C
+	}
+}
+
+// checkNodeHealth 定期刷新节点健康，definition.HealthDebug 为 true 时打印评估结果
+func checkNodeHealth(scheduler *pkg.CustomScheduler) {
+	ticker := time.NewTicker(definition.HealthRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := scheduler.Health.Refresh(); err != nil {
+			fmt.Printf("刷新节点健康错误: %v\n", err)
+		} else if definition.HealthDebug {
+			fmt.Print(scheduler.Health.Report())
+		}
+		<-ticker.C
+	}
+}
+
+// refreshNodeImages 定期刷新各节点缓存的镜像
+func refreshNodeImages(scheduler *pkg.CustomScheduler) {
+	ticker := time.NewTicker(definition.ImageRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := scheduler.Images.Refresh(); err != nil {
+			fmt.Printf("刷新节点镜像错误: %v\n", err)
+		}
+		<-ticker.C
+	}
+}
+
 // printMetrics 打印调度器指标
 func printMetrics() {
 	ticker := time.NewTicker(schedulerInterval)
@@ -212,6 +277,11 @@ func printMetrics() {
 		fmt.Printf("失败调度数: %d\n", metrics.FailedSchedules)
 		fmt.Printf("当前活跃调度数: %d\n", metrics.ActiveSchedules)
 		fmt.Printf("队列长度: %d\n", metrics.QueueLength)
+		hits, misses := utils.Cache.Stats()
+		fmt.Printf("监控缓存命中/未命中: %d/%d\n", hits, misses)
+		if unmapped := utils.UnmappedInstances(); len(unmapped) > 0 {
+			fmt.Printf("无法映射到节点的 instance: %v\n", unmapped)
+		}
 		fmt.Printf("================\n\n")
 		metrics.Unlock()
 	}
@@ -9,15 +9,28 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"math"
+	"sync"
+	"time"
 )
 
 type CustomScheduler struct {
-	Clientset     *kubernetes.Clientset        // 用于调用 k8s API
-	K8sNodes      []*corev1.Node               // k8s 节点对象集合（云节点）
-	K8sNodesName  []string                     // k8s 节点名称集合
-	MyNodes       map[string]*definition.Node  // 转换后的自定义 Node 对象，key 为节点名称
-	NodePods      map[string][]*definition.Pod // 每个节点上已有 Pod 的集合
-	SchedulerName string                       // 调度器名称
+	Clientset     *kubernetes.Clientset              // 用于调用 k8s API
+	K8sNodes      []*corev1.Node                     // k8s 节点对象集合（definition.Tiers 中所有层级的节点）
+	K8sNodesName  []string                           // k8s 节点名称集合
+	MyNodes       map[string]*definition.Node        // 转换后的自定义 Node 对象，key 为节点名称
+	NodePods      map[string][]*definition.Pod       // 每个节点上已有 Pod 的集合
+	Reservations  map[string]*definition.Reservation // 每个节点的资源预留量，由 definition.ReservationPolicies 解析
+	CPUFactors    map[string]float64                 // 每个节点的 CPU 性能系数，由 definition.CPUPerformancePolicies 和节点注解解析
+	DiskIOPS      map[string]float64                 // 每个节点磁盘的 IOPS 能力，由 definition.DiskIOPSPolicies 和节点注解解析
+	PowerModels   map[string]*definition.PowerModel  // 每个节点的功耗模型，由 definition.PowerModels 解析
+	NodeCosts     map[string]nodeCost                // 每个节点的小时成本，由 definition.NodeCosts 和节点注解解析
+	Profiler      *utils.WorkloadProfiler            // 工作负载实际用量画像，用于预测新 Pod 的真实用量
+	Health        *utils.NodeHealthChecker           // 节点健康评估，用于过滤和扣分
+	Images        *utils.NodeImageCache              // 各节点缓存的镜像，用于镜像本地性加分
+	SchedulerName string                             // 调度器名称
+
+	podsLock  sync.RWMutex              // 保护 NodePods，调度协程与事件监听协程会并发访问
+	lastKnown map[string]lastKnownUsage // 每个节点最近一次有效的使用量，仅调度协程访问
 }
 
 // NewCustomScheduler 创建 CustomScheduler 实例
@@ -27,13 +40,13 @@ func NewCustomScheduler(schedulerName string) (*CustomScheduler, error) {
 		schedulerName = definition.SchedulerName
 	}
 	// 获取 k8s 节点对象集合和名称集合
-	k8sNodes, err := utils.K8sNodesAvailable(true)
+	k8sNodes, err := utils.K8sTierNodesAvailable()
 	if err != nil {
 		return nil, err
 	}
-	k8sNodesName, err := utils.K8sNodesAvailableNames(true)
-	if err != nil {
-		return nil, err
+	var k8sNodesName []string
+	for _, n := range k8sNodes {
+		k8sNodesName = append(k8sNodesName, n.ObjectMeta.Name)
 	}
 	// 将 k8s 节点转换为自定义 Node 对象
 	nodes, err := utils.ConvertAllK8sNodesToMyNodes()
@@ -45,6 +58,28 @@ func NewCustomScheduler(schedulerName string) (*CustomScheduler, error) {
 	if err != nil {
 		return nil, err
 	}
+	// 解析每个节点的资源预留策略
+	reservations := make(map[string]*definition.Reservation)
+	cpuFactors := make(map[string]float64)
+	diskIOPS := make(map[string]float64)
+	powerModels := make(map[string]*definition.PowerModel)
+	nodeCosts := make(map[string]nodeCost)
+	for _, n := range k8sNodes {
+		hourly, spot := utils.ResolveNodeCost(definition.NodeCosts, n)
+		nodeCosts[n.ObjectMeta.Name] = nodeCost{hourly: hourly, spot: spot}
+		cpuFactors[n.ObjectMeta.Name] = utils.ResolveCPUFactor(definition.CPUPerformancePolicies, n)
+		diskIOPS[n.ObjectMeta.Name] = utils.ResolveDiskIOPS(definition.DiskIOPSPolicies, n)
+		powerModels[n.ObjectMeta.Name] = utils.ResolvePowerModel(definition.PowerModels, n)
+		customNode, ok := nodes[n.ObjectMeta.Name]
+		if !ok {
+			continue
+		}
+		reservation, err := utils.ResolveReservation(definition.ReservationPolicies, n, customNode)
+		if err != nil {
+			return nil, err
+		}
+		reservations[n.ObjectMeta.Name] = reservation
+	}
 
 	return &CustomScheduler{
 		Clientset:     definition.ClientSet,
@@ -52,22 +87,75 @@ func NewCustomScheduler(schedulerName string) (*CustomScheduler, error) {
 		K8sNodesName:  k8sNodesName,
 		MyNodes:       nodes,
 		NodePods:      nodePods,
+		Reservations:  reservations,
+		CPUFactors:    cpuFactors,
+		DiskIOPS:      diskIOPS,
+		PowerModels:   powerModels,
+		NodeCosts:     nodeCosts,
+		Profiler:      utils.NewWorkloadProfiler(),
+		Health:        utils.NewNodeHealthChecker(),
+		Images:        utils.NewNodeImageCache(),
+		lastKnown:     make(map[string]lastKnownUsage),
 		SchedulerName: schedulerName,
 	}, nil
 }
 
+// Decision 一次调度决策的结果
+type Decision struct {
+	Node     *corev1.Node // 选中的节点，为 nil 表示不可调度
+	Tier     string       // 选中节点所在的层级
+	Fallback string       // 使用的兜底策略，正常选择时为空
+	Reason   string       // 选择该节点或不可调度的原因
+}
+
+// candidate 本轮调度中有监控数据的候选节点
+type candidate struct {
+	k8sNode      *corev1.Node
+	node         *definition.Node
+	reservation  *definition.Reservation
+	cpuUsed      float64 // 实际 CPU 使用量（毫核）
+	memUsed      float64 // 实际内存使用量（字节）
+	usageSource  string  // 使用量的数据来源，取值见 usage* 常量
+	cpuPredicted float64 // 预测的 CPU 使用量（毫核），用于打分
+	memPredicted float64 // 预测的内存使用量（字节），用于打分
+	storageUsed  float64 // 实际根文件系统使用量（字节），hasStorage 为 false 时无数据
+	hasStorage   bool
+	netBusy      float64 // 最繁忙网卡较繁忙方向的利用率（0~1），hasNet 为 false 时无数据
+	netCapacity  float64 // 网卡带宽（字节/秒），用于折算 Pod 声明的吞吐
+	hasNet       bool
+	diskBusy     float64 // 物理磁盘繁忙时间比例，hasDisk 为 false 时无数据
+	diskIOPS     float64 // 物理磁盘 IOPS
+	diskCapacity float64 // 物理磁盘的 IOPS 能力
+	hasDisk      bool
+	pressure     map[string]float64     // 压力信号的值，只包含有数据的信号
+	health       *definition.NodeHealth // 节点健康评估结果，为 nil 时视为健康
+	cpuFactor    float64                // CPU 性能系数，基准节点为 1
+	tier         string                 // 节点所在的层级
+	rtt          float64                // 到 Pod 时延目标的往返时延（秒），hasRTT 为 false 时未知
+	hasRTT       bool
+	cost         nodeCost // 节点的小时成本
+}
+
+// nodeCost 节点的小时成本及是否为 spot 实例
+type nodeCost struct {
+	hourly float64
+	spot   bool
+}
+
 // Schedule 根据传入的 k8sPod 进行调度
 func (cs *CustomScheduler) Schedule(k8sPod *corev1.Pod) error {
 	fmt.Printf("---->调度pod: %s <----\n", k8sPod.ObjectMeta.Name)
 	// 转换 k8sPod 为自定义 Pod 对象
 	t0 := utils.ConvertK8sPodToMyPod(k8sPod)
 	// 选择合适的节点
-	chosenNode := cs.MBCTG(t0)
-	if chosenNode == nil {
-		return fmt.Errorf("未找到满足资源需求的节点")
+	decision := cs.MBCTG(t0)
+	if decision.Node == nil {
+		cs.markUnschedulable(k8sPod, decision.Reason)
+		return fmt.Errorf("未找到满足资源需求的节点: %s", decision.Reason)
 	}
+	chosenNode := decision.Node
 	// 根据选择的节点名称从自定义 MyNodes 中获取节点对象
-	fmt.Printf("调度至节点：%s\n", chosenNode.ObjectMeta.Name)
+	fmt.Printf("调度至%s层节点：%s（%s）\n", decision.Tier, chosenNode.ObjectMeta.Name, decision.Reason)
 	customNode, ok := cs.MyNodes[chosenNode.ObjectMeta.Name]
 	if !ok {
 		return fmt.Errorf("自定义节点中未找到: %s", chosenNode.ObjectMeta.Name)
@@ -82,104 +170,156 @@ func (cs *CustomScheduler) Schedule(k8sPod *corev1.Pod) error {
 }
 
 // MBCTG 合作博弈论
-func (cs *CustomScheduler) MBCTG(t0 *definition.Pod) *corev1.Node {
-	nodesCPU, err := utils.HttpGetNodeMonitor("cpu")
-	if err != nil {
-		fmt.Println("获取节点 CPU 监控数据错误:", err)
-		return nil
+func (cs *CustomScheduler) MBCTG(t0 *definition.Pod) *Decision {
+	// 本轮调度的所有监控数据来自同一份快照
+	snapshot := utils.Cache.Snapshot()
+	if snapshot.Stale {
+		fmt.Printf("监控快照正在刷新，使用 %s 前的快照\n", time.Since(snapshot.Time).Round(time.Second))
 	}
-	nodesMem, err := utils.HttpGetNodeMonitor("mem")
-	if err != nil {
-		fmt.Println("获取节点内存监控数据错误:", err)
-		return nil
+	// 获取节点使用量，缺失或过期的数据按 definition.MissingMetricsPolicy 处理
+	usage := cs.collectUsage(snapshot)
+	if len(usage) == 0 {
+		return &Decision{Reason: "没有节点具备有效的监控数据"}
 	}
-	var chosenNode *corev1.Node
-	var HMax float64 = math.Inf(-1)
-	// 遍历所有 k8s 节点
+	// 打分使用预测的使用量，预测失败或节点数据不是实时数据时使用当前值
+	predictedCPU := forecastUsage(snapshot, "cpu")
+	predictedMem := forecastUsage(snapshot, "mem")
+	// 均衡打分用到的其他资源维度，获取失败时该维度按缺失处理
+	nodesStorage := balanceMonitor(snapshot, definition.ResourceStorage, "storage")
+	nodesNet := balanceMonitor(snapshot, definition.ResourceNetwork, "net")
+	nodesNetSpeed := balanceMonitor(snapshot, definition.ResourceNetwork, "net-speed")
+	nodesDiskIO := balanceMonitor(snapshot, definition.ResourceDiskIO, "disk-io")
+	nodesDiskIOPS := balanceMonitor(snapshot, definition.ResourceDiskIO, "disk-iops")
+	pressure := collectPressure(snapshot)
+	// 收集有使用量数据的候选节点
+	var candidates []*candidate
 	for _, n := range cs.K8sNodes {
 		customNode, ok := cs.MyNodes[n.ObjectMeta.Name]
 		if !ok {
 			continue
 		}
-		cpuUsed, cpuOk := nodesCPU[n.ObjectMeta.Name]
-		memUsed, memOk := nodesMem[n.ObjectMeta.Name]
-		if !cpuOk || !memOk {
+		u, ok := usage[n.ObjectMeta.Name]
+		if !ok {
 			continue
 		}
-		// 过滤
-		cpuLeft := customNode.CapacityCPU - cpuUsed
-		memLeft := customNode.CapacityMemory - memUsed
-		if t0.CPURequest > cpuLeft || t0.MemoryRequest > memLeft {
-			continue
+		c := &candidate{
+			k8sNode:     n,
+			node:        customNode,
+			reservation: cs.Reservations[n.ObjectMeta.Name],
+			cpuUsed:     u.cpu,
+			memUsed:     u.mem,
+			usageSource: u.source,
 		}
-		if n.ObjectMeta.Name == "master" {
-			if cpuLeft/1000 < 2 || memLeft/(1<<30) < 4 {
-				continue
+		c.cpuPredicted, c.memPredicted = u.cpu, u.mem
+		if u.source == usageLive {
+			if val, ok := predictedCPU[n.ObjectMeta.Name]; ok {
+				c.cpuPredicted = val
 			}
+			if val, ok := predictedMem[n.ObjectMeta.Name]; ok {
+				c.memPredicted = val
+			}
+		}
+		c.storageUsed, c.hasStorage = nodesStorage[n.ObjectMeta.Name]
+		c.netBusy, c.hasNet = nodesNet[n.ObjectMeta.Name]
+		c.netCapacity = definition.DefaultNetworkBandwidth
+		if speed, ok := nodesNetSpeed[n.ObjectMeta.Name]; ok && speed > 0 {
+			c.netCapacity = speed
 		}
-		cpuUsedRate := (cpuUsed + t0.CPURequest) / customNode.CapacityCPU
-		memUsedRate := (memUsed + t0.MemoryRequest) / customNode.CapacityMemory
-		miu := (cpuUsedRate + memUsedRate) / 2
-		variance := (math.Pow(cpuUsedRate-miu, 2) + math.Pow(memUsedRate-miu, 2)) / 2
-		fmt.Printf("%s方差：%f\n", n.ObjectMeta.Name, variance)
-		H := 10 - 100*variance
-		H *= math.Pow(10, float64(len(cs.K8sNodes)-1))
-		if H > HMax {
-			HMax = H
-			chosenNode = n
+		c.diskBusy, c.hasDisk = nodesDiskIO[n.ObjectMeta.Name]
+		c.diskIOPS = nodesDiskIOPS[n.ObjectMeta.Name]
+		c.diskCapacity = definition.DefaultDiskIOPS
+		if iops, ok := cs.DiskIOPS[n.ObjectMeta.Name]; ok {
+			c.diskCapacity = iops
 		}
+		c.pressure = pressure[n.ObjectMeta.Name]
+		c.health = cs.Health.Get(n.ObjectMeta.Name)
+		c.tier = utils.NodeTier(n.ObjectMeta.Name)
+		c.cost = cs.NodeCosts[n.ObjectMeta.Name]
+		c.cpuFactor = 1
+		if factor, ok := cs.CPUFactors[n.ObjectMeta.Name]; ok {
+			c.cpuFactor = factor
+		}
+		candidates = append(candidates, c)
 	}
-	// 兜底逻辑
-	if chosenNode == nil {
-		sumDict := make(map[string]float64)
-		for key := range nodesCPU {
-			if memVal, exists := nodesMem[key]; exists {
-				sumDict[key] = math.Abs(nodesCPU[key] + memVal)
-			}
+
+	// 集群均衡在所有有使用量数据的节点间计算，不随 Pod 的硬性约束变化
+	all := candidates
+	// 硬性约束不满足的节点直接排除，兜底策略也不会选择；时延约束需要先测量
+	cs.measureLatency(t0, candidates)
+	candidates = cs.eligibleCandidates(t0, candidates)
+
+	// 打分使用画像预测的真实用量，过滤仍按请求量
+	scored := cs.predictFootprint(t0)
+
+	decision := cs.decide(t0, scored, candidates, all)
+	if definition.EnergyMode {
+		cs.reportPower(scored, all, decision)
+	}
+	return decision
+}
+
+// decide 两级调度：先按 Pod 注解和层级容量选择层级，再在层级内进行节点博弈，都失败时使用兜底策略；
+// all 为过滤硬性约束前的所有节点，用于计算集群均衡
+func (cs *CustomScheduler) decide(t0, scored *definition.Pod, candidates, all []*candidate) *Decision {
+	req := podTierRequest(t0)
+	var tried []*candidate
+	for _, tier := range tierOrder(req) {
+		tierCandidates := candidatesInTier(tier, req, candidates)
+		// 容量不足的层级跳过博弈，但仍交给兜底策略：层级容量扣除了预留量，兜底策略不考虑预留
+		tried = append(tried, tierCandidates...)
+		if ok, reason := cs.tierHasCapacity(t0, tierCandidates); !ok {
+			fmt.Printf("跳过%s层：%s\n", tier, reason)
+			continue
 		}
-		var chosenNodeName string
-
-		switch {
-		case t0.CPURequest >= 4000:
-			// 找nodesCPU中值最小的节点
-			minVal := math.MaxFloat64
-			for key, val := range nodesCPU {
-				if val < minVal {
-					minVal = val
-					chosenNodeName = key
-				}
-			}
-		case t0.MemoryRequest > 10*(1<<30): // 1 << 30 = 1073741824 (1GB)
-			// 找nodesMem中值最小的节点
-			minVal := math.MaxFloat64
-			for key, val := range nodesMem {
-				if val < minVal {
-					minVal = val
-					chosenNodeName = key
-				}
-			}
-		default:
-			// 找sumDict中值最小的节点
-			minVal := math.MaxFloat64
-			for key, val := range sumDict {
-				if val < minVal {
-					minVal = val
-					chosenNodeName = key
-				}
-			}
+		if decision := cs.playGame(t0, scored, tierCandidates, all); decision != nil {
+			decision.Tier = tier
+			return decision
+		}
+		fmt.Printf("%s层没有节点通过过滤\n", tier)
+	}
+	// 兜底逻辑只在尝试过的层级中选择（包括容量不足而跳过的层级）
+	decision := cs.fallback(t0, tried)
+	if decision.Node != nil {
+		decision.Tier = utils.NodeTier(decision.Node.ObjectMeta.Name)
+	}
+	fmt.Printf("没有节点通过过滤，使用兜底策略 %s：%s\n", decision.Fallback, decision.Reason)
+	return decision
+}
+
+// playGame 在 candidates 中过滤并选择收益最高的节点，没有节点通过过滤时返回 nil；
+// 集群均衡项按 all（所有层级中有使用量数据的节点，不论是否满足 Pod 的硬性约束）计算，避免只在部分节点内求均衡
+func (cs *CustomScheduler) playGame(t0, scored *definition.Pod, candidates, all []*candidate) *Decision {
+	var chosen *scoreDetail
+	var chosenNode *corev1.Node
+	var HMax float64 = math.Inf(-1)
+	// 遍历所有候选节点
+	for _, c := range candidates {
+		// 过滤
+		if ok, reason := cs.nodeFitsResources(t0, c); !ok {
+			fmt.Printf("%s被过滤：%s\n", c.node.Name, reason)
+			continue
 		}
-		// 获取K8s Pod对象
-		if chosenNodeName != "" {
-			chosenNode, _ = utils.GetK8sNodeByName(chosenNodeName)
+		detail := cs.scoreNode(scored, c, all)
+		fmt.Println(detail)
+		if detail.score > HMax {
+			HMax = detail.score
+			chosen = detail
+			chosenNode = c.k8sNode
 		}
 	}
-	return chosenNode
+	if chosenNode == nil {
+		return nil
+	}
+	return &Decision{Node: chosenNode, Reason: fmt.Sprintf("收益最高 %f", chosen.score)}
 }
 
 // judge 打印当前节点的监控数据
 func (cs *CustomScheduler) judge() {
-	_ = utils.PrintNodeMonitorToRead("cpu")
-	_ = utils.PrintNodeMonitorToRead("mem")
+	for _, req := range []string{"cpu", "mem"} {
+		if err := utils.PrintNodeMonitorToRead(req); err != nil {
+			fmt.Printf("打印节点 %s 监控数据错误: %v\n", req, err)
+		}
+	}
 }
 
 // bind 调用 k8s API 将 Pod 绑定到指定节点
@@ -219,22 +359,72 @@ func (cs *CustomScheduler) placePod(k8sPod *corev1.Pod, node *definition.Node) {
 		return
 	}
 	pod := utils.ConvertK8sPodToMyPod(k8sPod)
-	cs.NodePods[node.Name] = append(cs.NodePods[node.Name], pod)
+	// 待调度 Pod 的 spec.nodeName 为空，以绑定的节点为准
+	pod.Node = node.Name
+	cs.addNodePod(pod)
+}
+
+// TrackPod 记录已绑定到节点且未结束的 Pod（包括其他调度器调度的 Pod），用于请求量统计
+func (cs *CustomScheduler) TrackPod(k8sPod *corev1.Pod) {
+	if k8sPod.Spec.NodeName == "" || utils.IsPodTerminated(k8sPod) {
+		return
+	}
+	cs.addNodePod(utils.ConvertK8sPodToMyPod(k8sPod))
+}
+
+// addNodePod 将 Pod 加入所在节点的记录，已存在的同名 Pod 会被替换
+func (cs *CustomScheduler) addNodePod(pod *definition.Pod) {
+	cs.podsLock.Lock()
+	defer cs.podsLock.Unlock()
+	cs.removeNodePodLocked(pod)
+	cs.NodePods[pod.Node] = append(cs.NodePods[pod.Node], pod)
+}
+
+// removeNodePodLocked 从所有节点的记录中删除指定 Pod，调用方需持有 podsLock
+func (cs *CustomScheduler) removeNodePodLocked(removedPod *definition.Pod) {
+	for nodeName, plist := range cs.NodePods {
+		var newList []*definition.Pod
+		for _, p := range plist {
+			if p.Key() != removedPod.Key() {
+				newList = append(newList, p)
+			}
+		}
+		cs.NodePods[nodeName] = newList
+	}
 }
 
 // UpdateNodePods 删除指定 Pod 的记录（用于 Pod 删除更新）
 func (cs *CustomScheduler) UpdateNodePods(k8sPod *corev1.Pod) {
 	removedPod := utils.ConvertK8sPodToMyPod(k8sPod)
 	fmt.Printf("---->删除pod: %s <----\n", removedPod.Name)
-	plist, ok := cs.NodePods[removedPod.Node]
-	if !ok {
-		return
-	}
-	var newList []*definition.Pod
-	for _, p := range plist {
-		if p.Name != removedPod.Name {
-			newList = append(newList, p)
-		}
+	cs.podsLock.Lock()
+	defer cs.podsLock.Unlock()
+	cs.removeNodePodLocked(removedPod)
+}
+
+// requestedResources 节点上已放置 Pod 的请求量之和
+type requestedResources struct {
+	cpu     float64 // CPU 请求量（毫核）
+	mem     float64 // 内存请求量（字节）
+	storage float64 // 临时存储请求量（字节）
+	pods    float64 // Pod 数
+
+	cpuLimits float64 // CPU limit 之和（毫核），未设置 limit 的容器按 request 计
+	memLimits float64 // 内存 limit 之和（字节），未设置 limit 的容器按 request 计
+}
+
+// nodeRequested 统计节点上已放置 Pod 的请求量之和及 Pod 数
+func (cs *CustomScheduler) nodeRequested(nodeName string) requestedResources {
+	cs.podsLock.RLock()
+	defer cs.podsLock.RUnlock()
+	var r requestedResources
+	for _, p := range cs.NodePods[nodeName] {
+		r.cpu += p.CPURequest
+		r.mem += p.MemoryRequest
+		r.storage += p.StorageRequest
+		r.pods++
+		r.cpuLimits += p.CPULimits
+		r.memLimits += p.MemoryLimits
 	}
-	cs.NodePods[removedPod.Node] = newList
+	return r
 }
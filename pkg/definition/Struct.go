@@ -1,48 +1,230 @@
 package definition
 
-import corev1 "k8s.io/api/core/v1"
+import (
+	corev1 "k8s.io/api/core/v1"
+	"strings"
+	"time"
+)
 
 type Node struct {
-	IP                string      // 节点 IP
-	Name              string      // 节点名称
-	K8sNode           interface{} // k8s_node 对象，可以根据需要替换为具体类型
-	CapacityCPU       float64     // 节点 CPU 总量
-	AllocatableCPU    float64     // 节点 CPU 可用量
-	CapacityMemory    float64     // 节点内存总容量
-	AllocatableMemory float64     // 节点内存可用容量
+	IP                 string      // 节点 IP
+	Name               string      // 节点名称
+	K8sNode            interface{} // k8s_node 对象，可以根据需要替换为具体类型
+	CapacityCPU        float64     // 节点 CPU 总量
+	AllocatableCPU     float64     // 节点 CPU 可用量
+	CapacityMemory     float64     // 节点内存总容量
+	AllocatableMemory  float64     // 节点内存可用容量
+	CapacityStorage    float64     // 节点临时存储总容量（字节）
+	AllocatableStorage float64     // 节点临时存储可用容量（字节）
+	CapacityPods       float64     // 节点可容纳 Pod 总数
+	AllocatablePods    float64     // 节点可分配 Pod 数
 }
 
 type Pod struct {
-	Name          string      // Pod 名称
-	Node          string      // Pod 所在节点
-	K8sPod        interface{} // k8s 的 Pod 对象，可替换为具体类型
-	MemoryRequest float64     // Pod 的内存请求
-	CPURequest    float64     // Pod 的 CPU 请求
-	MemoryLimits  float64     // Pod 的内存限制
-	CPULimits     float64     // Pod 的 CPU 限制
+	Name           string      // Pod 名称
+	Namespace      string      // Pod 所在命名空间
+	Node           string      // Pod 所在节点
+	K8sPod         interface{} // k8s 的 Pod 对象，可替换为具体类型
+	MemoryRequest  float64     // Pod 的内存请求
+	CPURequest     float64     // Pod 的 CPU 请求
+	MemoryLimits   float64     // Pod 的内存限制，未设置 limit 的容器按 request 计
+	CPULimits      float64     // Pod 的 CPU 限制，未设置 limit 的容器按 request 计
+	StorageRequest float64     // Pod 的临时存储请求
+	NetBandwidth   float64     // Pod 通过注解声明的网络吞吐（字节/秒）
+	DiskIOPS       float64     // Pod 通过注解声明的磁盘 IOPS
+	// PerformanceSensitive Pod 通过注解声明对 CPU 性能敏感，过滤和打分时 CPU 按节点性能系数换算
+	PerformanceSensitive bool
+	// Architectures Pod 所有镜像都支持的 CPU 架构（如 amd64、arm64），为空表示不限制
+	Architectures []string
+	// LatencyTarget Pod 依赖的服务、节点或站点，MaxRTT 到该目标的最大往返时延，为 0 表示只打分不过滤
+	LatencyTarget string
+	MaxRTT        time.Duration
+	// CostClass Pod 的成本敏感等级，取值见 CostClass* 常量；InterruptionTolerant 能否容忍 spot 节点被回收
+	CostClass            string
+	InterruptionTolerant bool
 }
 
 // NewPod 构造函数
-func NewPod(name string, node string, k8sPod *corev1.Pod, memoryRequest, cpuRequest, memoryLimits, cpuLimits float64) *Pod {
+func NewPod(name string, namespace string, node string, k8sPod *corev1.Pod, memoryRequest, cpuRequest, memoryLimits, cpuLimits,
+	storageRequest, netBandwidth, diskIOPS float64, performanceSensitive bool, architectures []string) *Pod {
 	return &Pod{
-		Name:          name,
-		Node:          node,
-		K8sPod:        k8sPod,
-		MemoryRequest: memoryRequest,
-		CPURequest:    cpuRequest,
-		MemoryLimits:  memoryLimits,
-		CPULimits:     cpuLimits,
+		Name:                 name,
+		Namespace:            namespace,
+		Node:                 node,
+		K8sPod:               k8sPod,
+		MemoryRequest:        memoryRequest,
+		CPURequest:           cpuRequest,
+		MemoryLimits:         memoryLimits,
+		CPULimits:            cpuLimits,
+		StorageRequest:       storageRequest,
+		NetBandwidth:         netBandwidth,
+		DiskIOPS:             diskIOPS,
+		PerformanceSensitive: performanceSensitive,
+		Architectures:        architectures,
 	}
 }
 
-func NewNode(ip string, name string, k8sNode *corev1.Node, capacityCpu, allocatableCpu, capacityMemory, allocatableMemory float64) *Node {
+func NewNode(ip string, name string, k8sNode *corev1.Node, capacityCpu, allocatableCpu, capacityMemory, allocatableMemory,
+	capacityStorage, allocatableStorage, capacityPods, allocatablePods float64) *Node {
 	return &Node{
-		IP:                ip,
-		Name:              name,
-		K8sNode:           k8sNode,
-		CapacityCPU:       capacityCpu,
-		AllocatableCPU:    allocatableCpu,
-		CapacityMemory:    capacityMemory,
-		AllocatableMemory: allocatableMemory,
+		IP:                 ip,
+		Name:               name,
+		K8sNode:            k8sNode,
+		CapacityCPU:        capacityCpu,
+		AllocatableCPU:     allocatableCpu,
+		CapacityMemory:     capacityMemory,
+		AllocatableMemory:  allocatableMemory,
+		CapacityStorage:    capacityStorage,
+		AllocatableStorage: allocatableStorage,
+		CapacityPods:       capacityPods,
+		AllocatablePods:    allocatablePods,
 	}
 }
+
+// Key 返回 Pod 的唯一标识 namespace/name
+func (p *Pod) Key() string {
+	return PodKey(p.Namespace, p.Name)
+}
+
+// PodKey 返回 Pod 的唯一标识 namespace/name，所有按 Pod 索引的数据都使用该格式
+func PodKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// SplitPodKey 将 PodKey 拆分为命名空间和名称，没有命名空间时 namespace 为空
+func SplitPodKey(key string) (namespace, name string) {
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return "", key
+}
+
+// NodeSelector 按节点名称或标签选择节点的策略：名称命中，或标签全部满足即选中
+type NodeSelector struct {
+	Name       string            // 策略名称，用于打分说明和日志
+	NodeNames  []string          // 按节点名称匹配
+	NodeLabels map[string]string // 按节点标签匹配（需全部满足），值为空表示只要求存在该标签
+}
+
+// ReservationPolicy 节点资源预留策略
+type ReservationPolicy struct {
+	NodeSelector
+	CPU    string // CPU 预留：绝对值（如 "2"、"500m"）或容量百分比（如 "10%"）
+	Memory string // 内存预留：绝对值（如 "4Gi"）或容量百分比（如 "20%"）
+	Pods   string // Pod 数预留：绝对值（如 "10"）或容量百分比（如 "5%"）
+}
+
+// CPUPerformancePolicy 节点 CPU 性能系数
+type CPUPerformancePolicy struct {
+	NodeSelector
+	Factor float64 // 每毫核相当于基准节点的计算单位数，基准节点为 1
+}
+
+// DiskIOPSPolicy 节点磁盘的 IOPS 能力
+type DiskIOPSPolicy struct {
+	NodeSelector
+	IOPS float64 // 磁盘每秒可完成的读写次数
+}
+
+// Tier 云边分层调度中的一个层级
+type Tier struct {
+	Name      string   // 层级名称，取值见 Tier* 常量
+	NodeNames []string // 属于该层级的节点
+}
+
+// TierRequest Pod 通过注解声明的分层调度需求
+type TierRequest struct {
+	Tier             string // 指定层级，为空时按其他注解选择
+	LatencySensitive bool   // 延迟敏感，优先边缘层
+	ComputeHeavy     bool   // 计算密集，优先云层
+	EdgeSite         string // 数据所在的边缘站点，边缘层只选择该站点的节点
+	Fallback         string // 首选层级没有可用节点时的跨层策略，取值见 TierFallback* 常量
+}
+
+// PowerModel 节点功耗模型
+type PowerModel struct {
+	NodeSelector
+	IdleWatts float64 // CPU 空闲时的功耗（瓦）
+	MaxWatts  float64 // CPU 满载时的功耗（瓦）
+	// Curve 分段线性模型：CPU 使用率从 0 到 1 等间距各点的功耗（瓦），至少 2 个点；为空时在 IdleWatts 与 MaxWatts 间线性插值
+	Curve []float64
+}
+
+// NodeCost 节点的小时成本
+type NodeCost struct {
+	NodeSelector
+	HourlyCost float64 // 每小时成本
+	Spot       bool    // 是否为可被回收的 spot 实例
+}
+
+// Reservation 解析后作用于某一节点的预留量
+type Reservation struct {
+	CPU      float64  // 预留 CPU（毫核）
+	Memory   float64  // 预留内存（字节）
+	Pods     float64  // 预留 Pod 数
+	Policies []string // 生效的策略名称
+}
+
+// ResourceWeight 均衡打分中的资源维度及其权重
+type ResourceWeight struct {
+	Name   string  // 资源名称，取值见 Resource* 常量
+	Weight float64 // 权重，越大该资源的不均衡越受重视
+}
+
+// WorkloadProfile 工作负载（Deployment/StatefulSet/Job 等）的历史实际用量画像
+type WorkloadProfile struct {
+	Workload  string    // 工作负载标识 namespace/Kind/name
+	CPUP50    float64   // CPU 用量 p50（毫核）
+	CPUP95    float64   // CPU 用量 p95（毫核）
+	MemP50    float64   // 内存用量 p50（字节）
+	MemP95    float64   // 内存用量 p95（字节）
+	Samples   int       // 参与统计的样本数
+	UpdatedAt time.Time // 画像更新时间
+}
+
+// LimitRiskPolicy 按 limit 总和建模的节点最坏情况超售风险策略
+type LimitRiskPolicy struct {
+	MaxRatio float64 // limit 总和（含新 Pod）/ Allocatable 的上限
+	Block    bool    // 超过上限时直接过滤，否则只扣分
+	Weight   float64 // 扣分权重：扣分 = Weight * LimitRiskScale * max(0, 比值 - 1)
+}
+
+// PressurePolicy 节点压力信号的干扰策略
+type PressurePolicy struct {
+	Signal    string  // 压力信号，取值见 Pressure* 常量
+	Threshold float64 // 持续压力的阈值
+	Block     bool    // 超过阈值时直接过滤，否则只扣分
+	Weight    float64 // 扣分权重：扣分 = Weight * PressureScale * 压力 / 阈值
+}
+
+// HealthPolicy 节点健康信号的处理策略
+type HealthPolicy struct {
+	Signal    string  // 节点条件类型（如 MemoryPressure）或 Health* 常量
+	Threshold float64 // 信号值达到该值时视为不健康，节点条件为 True 时信号值为 1
+	Block     bool    // 不健康时直接过滤，否则扣除 Penalty 健康分
+	Penalty   float64 // 不健康时扣除的健康分（满分 100）
+}
+
+// NodeHealth 节点健康评估结果
+type NodeHealth struct {
+	Score     float64   // 健康分，0~100
+	Blocked   bool      // 存在 Block 信号不健康，节点应被过滤
+	Reasons   []string  // 不健康的原因
+	UpdatedAt time.Time // 评估时间
+}
+
+// PrometheusConfig Prometheus 客户端配置
+type PrometheusConfig struct {
+	Endpoints          []string      // Prometheus 地址，按顺序故障转移，例如 "http://192.168.3.221:31000"
+	Timeout            time.Duration // 单次查询超时
+	BearerToken        string        // Bearer Token 认证，BearerTokenFile 非空时优先读取文件
+	BearerTokenFile    string        // Bearer Token 文件，每次请求重新读取以支持轮换
+	Username           string        // Basic 认证用户名
+	Password           string        // Basic 认证密码
+	CAFile             string        // 自定义 CA 证书
+	CertFile           string        // mTLS 客户端证书
+	KeyFile            string        // mTLS 客户端私钥
+	InsecureSkipVerify bool          // 跳过服务端证书校验，仅用于测试
+	Retries            int           // 每个地址失败后的重试次数
+	RetryBackoff       time.Duration // 重试的基础退避时间，按 2^n 增长并叠加随机抖动
+}
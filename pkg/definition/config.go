@@ -3,6 +3,7 @@ package definition
 import (
 	"fmt"
 	"k8s.io/client-go/kubernetes"
+	"time"
 )
 
 // 常量定义
@@ -16,6 +17,151 @@ const (
 	CadvisorJob    = "cloud_cadvisor"
 	SplittingChar  = "-"
 	PodName        = ""
+	// PodNamespaceLabel、PodNameLabel、ContainerNameLabel cAdvisor 中表示 Pod 命名空间、Pod 名称和容器名称的标签
+	PodNamespaceLabel  = "container_label_io_kubernetes_pod_namespace"
+	PodNameLabel       = "container_label_io_kubernetes_pod_name"
+	ContainerNameLabel = "container_label_io_kubernetes_container_name"
+	// PodContainerGroupBy 按命名空间/Pod/容器分组
+	PodContainerGroupBy = PodNamespaceLabel + "," + PodNameLabel + "," + ContainerNameLabel
+	// PodContainerFilter 只保留业务容器，排除 pause 容器和 Pod 级汇总序列
+	PodContainerFilter = ContainerNameLabel + `!="",` + ContainerNameLabel + `!="POD"`
+	// VirtualNetDevices 统计网络吞吐时排除的虚拟网卡
+	VirtualNetDevices = "lo|veth.*|docker.*|cni.*|flannel.*|cali.*|tunl.*|vxlan.*|kube-ipvs.*"
+	// PhysicalDiskDevices 统计磁盘 I/O 时只保留的物理磁盘，排除 loop、dm 等虚拟设备避免重复计算
+	PhysicalDiskDevices = "sd.*|vd.*|xvd.*|hd.*|nvme[0-9]+n[0-9]+"
+	// PodNetworkBandwidthAnnotation Pod 声明的预期网络吞吐（字节/秒），取值为 Quantity，例如 "50M"
+	PodNetworkBandwidthAnnotation = "mbctg.io/network-bandwidth"
+	// PodDiskIOPSAnnotation Pod 声明的预期磁盘 IOPS，例如 "500"
+	PodDiskIOPSAnnotation = "mbctg.io/disk-iops"
+	// PodPerformanceSensitiveAnnotation 值为 "true" 时 Pod 的 CPU 请求按基准节点的计算单位解释
+	PodPerformanceSensitiveAnnotation = "mbctg.io/performance-sensitive"
+	// PodArchitecturesAnnotation Pod 声明镜像支持的 CPU 架构，逗号分隔，例如 "amd64,arm64"，优先于镜像清单缓存
+	PodArchitecturesAnnotation = "mbctg.io/architectures"
+	// NodeArchLabel 节点 CPU 架构标签
+	NodeArchLabel = "kubernetes.io/arch"
+	// NodeCPUFactorAnnotation 节点的 CPU 基准测试结果（相对基准节点的性能系数），优先于 CPUPerformancePolicies
+	NodeCPUFactorAnnotation = "mbctg.io/cpu-performance-factor"
+	// NodeDiskIOPSAnnotation 节点磁盘的 IOPS 能力（基准测试结果），优先于 DiskIOPSPolicies
+	NodeDiskIOPSAnnotation = "mbctg.io/disk-iops-capacity"
+)
+
+// 均衡打分的资源维度
+const (
+	ResourceCPU     = "cpu"               // CPU 使用率
+	ResourceMemory  = "memory"            // 内存使用率
+	ResourceStorage = "ephemeral-storage" // 根文件系统（临时存储）使用率
+	ResourceNetwork = "network"           // 网卡收发吞吐占带宽的比例
+	ResourceDiskIO  = "disk-io"           // 磁盘繁忙时间比例与 IOPS 占磁盘能力比例的较大者
+	ResourcePods    = "pods"              // Pod 数占可分配 Pod 数的比例
+)
+
+// 节点压力信号，用于识别内存带宽、缓存、I/O 等争用造成的相互干扰
+const (
+	PressureCPU    = "pressure-cpu"    // CPU PSI：有任务等待 CPU 的时间比例
+	PressureMemory = "pressure-memory" // 内存 PSI：有任务等待内存回收的时间比例
+	PressureIO     = "pressure-io"     // I/O PSI：有任务等待 I/O 的时间比例
+	PressureLoad   = "load-per-core"   // 5 分钟平均负载 / CPU 核数
+)
+
+// 来自 node-exporter 的节点健康信号，节点条件直接使用条件类型作为信号名
+const (
+	HealthOOMKills = "oom-kills" // HealthWindow 内内核 OOM kill 次数
+	HealthReboots  = "reboots"   // HealthWindow 内节点重启次数
+)
+
+// 均衡打分的离散度度量方式
+const (
+	DispersionVariance = "variance" // 加权方差
+	DispersionStdDev   = "stddev"   // 加权标准差
+	DispersionCV       = "cv"       // 变异系数（加权标准差 / 加权均值）
+	DispersionMaxMin   = "maxmin"   // 加权极差：各维度相对加权均值的偏差乘以相对权重后的最大值 - 最小值
+)
+
+// 节点使用量的预测方法
+const (
+	ForecastInstant     = "instant"      // 不预测，直接使用当前值
+	ForecastEWMA        = "ewma"         // 指数加权移动平均
+	ForecastHoltWinters = "holt-winters" // 加法 Holt-Winters，可捕捉周期性批处理峰值
+	ForecastPercentile  = "percentile"   // 窗口内样本的百分位数
+
+	ForecastAggregateMax        = "max"        // 预测区间内的最大值
+	ForecastAggregatePercentile = "percentile" // 预测区间内的百分位数
+)
+
+// 节点缺少有效（缺失或过期）监控数据时的处理方式
+const (
+	MissingMetricsExclude      = "exclude"       // 不参与本轮调度
+	MissingMetricsRequestsOnly = "requests-only" // 以已放置 Pod 的请求量作为使用量进行过滤和打分
+	MissingMetricsLastKnown    = "last-known"    // 使用最近一次有效数据，超过 LastKnownMaxAge 时不参与调度
+)
+
+// 监控数据源
+const (
+	MetricsBackendPrometheus    = "prometheus"     // Prometheus（node-exporter + cAdvisor）
+	MetricsBackendMetricsServer = "metrics-server" // Kubernetes metrics.k8s.io API，不支持历史数据
+	MetricsBackendStatic        = "static"         // 从 StaticMetricsFile 读取的固定数据，用于测试和演示
+)
+
+// Prometheus 序列到节点名称的映射方式
+const (
+	InstanceMappingInstance = "instance" // instance 标签即节点名称
+	InstanceMappingLabel    = "label"    // 使用 InstanceLabel 指定的标签
+	InstanceMappingAddress  = "address"  // 去掉 instance 的端口后按 IP 匹配节点地址
+	InstanceMappingUname    = "uname"    // 通过 node_uname_info 将 instance 关联到主机名
+)
+
+// 兜底策略：没有节点通过过滤时的处理方式
+const (
+	FallbackStrict      = "strict"       // 严格模式：不绑定，将 Pod 标记为 Unschedulable
+	FallbackLeastLoaded = "least-loaded" // 最小负载模式：在满足 kubelet 准入的节点中选择负载最低的节点，忽略预留和实际余量
+	FallbackOvercommit  = "overcommit"   // 超售模式：允许实际使用量按比例超出节点容量
+)
+
+// 节点间时延矩阵的数据源
+const (
+	LatencyBackendPrometheus = "prometheus" // blackbox-exporter 探测指标
+	LatencyBackendStatic     = "static"     // 从 LatencyFile 读取的固定矩阵
+)
+
+// 云边分层
+const (
+	TierCloud = "cloud" // 云层：算力充足，距离终端较远
+	TierEdge  = "edge"  // 边缘层：靠近数据源和终端，算力有限
+
+	TierFallbackNone = "none" // 只在首选层级内调度
+	TierFallbackAny  = "any"  // 首选层级没有可用节点时依次尝试其他层级
+
+	// PodTierAnnotation Pod 指定层级；PodLatencySensitiveAnnotation、PodComputeHeavyAnnotation 值为 "true" 时分别优先边缘层、云层
+	PodTierAnnotation             = "mbctg.io/tier"
+	PodLatencySensitiveAnnotation = "mbctg.io/latency-sensitive"
+	PodComputeHeavyAnnotation     = "mbctg.io/compute-heavy"
+	// PodEdgeSiteAnnotation Pod 数据所在的边缘站点，与节点 EdgeSiteLabel 标签匹配，隐含优先边缘层
+	PodEdgeSiteAnnotation = "mbctg.io/edge-site"
+	// PodTierFallbackAnnotation Pod 的跨层策略，取值见 TierFallback* 常量，未设置时使用 DefaultTierFallback
+	PodTierFallbackAnnotation = "mbctg.io/tier-fallback"
+	// EdgeSiteLabel 节点所在边缘站点的标签
+	EdgeSiteLabel = "topology.kubernetes.io/zone"
+
+	// PodLatencyTargetAnnotation Pod 依赖的目标：节点名称、边缘站点（EdgeSiteLabel 的值）或时延矩阵中的探测目标（如服务地址）
+	PodLatencyTargetAnnotation = "mbctg.io/latency-target"
+	// PodMaxRTTAnnotation Pod 到目标的最大往返时延，例如 "20ms"
+	PodMaxRTTAnnotation = "mbctg.io/max-rtt"
+)
+
+// Pod 的成本敏感等级
+const (
+	CostClassSensitive   = "sensitive"   // 成本敏感的批处理等任务，尽量放在便宜的节点
+	CostClassStandard    = "standard"    // 默认等级
+	CostClassInsensitive = "insensitive" // 不考虑成本
+
+	// PodCostClassAnnotation Pod 的成本敏感等级，未设置时为 CostClassStandard
+	PodCostClassAnnotation = "mbctg.io/cost-class"
+	// PodInterruptionTolerantAnnotation 值为 "true" 时 Pod 可以放在 spot 节点
+	PodInterruptionTolerantAnnotation = "mbctg.io/interruption-tolerant"
+	// NodeHourlyCostAnnotation 节点的小时成本，优先于 NodeCosts 中的配置
+	NodeHourlyCostAnnotation = "mbctg.io/hourly-cost"
+	// NodeSpotLabel 值为 "true" 的节点视为 spot 实例
+	NodeSpotLabel = "mbctg.io/spot"
 )
 
 // 变量定义
@@ -60,13 +206,245 @@ var (
 		"rasp5-arm": "192.168.3.220",
 	}
 
+	// ReservationPolicies 节点资源预留策略，过滤时要求放置后的剩余资源不低于预留量
+	// 多条策略命中同一节点时，每种资源取最大的预留量
+	ReservationPolicies = []ReservationPolicy{
+		{
+			NodeSelector: NodeSelector{
+				Name:       "control-plane",
+				NodeNames:  []string{MasterName},
+				NodeLabels: map[string]string{"node-role.kubernetes.io/control-plane": ""},
+			},
+			CPU:    "2",
+			Memory: "4Gi",
+		},
+	}
+
+	// Tiers 云边分层，顺序即跨层尝试的顺序；不属于任何层级的节点不参与调度
+	Tiers = []Tier{
+		{Name: TierCloud, NodeNames: CloudNodes},
+		{Name: TierEdge, NodeNames: append(append([]string{}, AmdEdgeNodes...), ArmEdgeNodes...)},
+	}
+	// DefaultTier 没有分层注解的 Pod 的首选层级
+	DefaultTier = TierCloud
+	// DefaultTierFallback 默认跨层策略；默认不跨层，Pod 需通过 PodTierFallbackAnnotation 显式允许
+	// （未声明架构的镜像跨到 ARM 边缘节点可能无法运行）
+	DefaultTierFallback = TierFallbackNone
+
+	// CPUPerformancePolicies 节点 CPU 性能系数，以云服务器为基准（1）；第一条命中的策略生效，未命中的节点为 1。
+	// 性能敏感的 Pod 在系数为 f 的节点上需要 请求量/f 毫核
+	CPUPerformancePolicies = []CPUPerformancePolicy{
+		{NodeSelector: NodeSelector{Name: "arm-edge", NodeNames: ArmEdgeNodes}, Factor: 0.35},
+		{NodeSelector: NodeSelector{Name: "amd-edge", NodeNames: AmdEdgeNodes}, Factor: 0.7},
+	}
+
+	// ImagePlatformsFile 本地镜像清单缓存，记录每个镜像支持的平台，文件更新后自动重新加载；为空或不存在时不使用
+	//	{"nginx:1.25": ["linux/amd64", "linux/arm64"], "registry.local/ingest:v2": ["linux/amd64"]}
+	ImagePlatformsFile = "image_platforms.json"
+
+	// EnergyMode 为 true 时按功耗模型估算每种放置的边际功耗并扣分，倾向于整合到已运行业务的节点，
+	// 同时不再计算集群间均衡（两者目标相反）
+	EnergyMode = false
+	// PowerModels 节点功耗模型，第一条命中的模型生效，未命中的节点使用 DefaultPowerModel
+	PowerModels = []PowerModel{
+		{NodeSelector: NodeSelector{Name: "cloud", NodeNames: CloudNodes}, IdleWatts: 90, MaxWatts: 250},
+		{NodeSelector: NodeSelector{Name: "amd-edge", NodeNames: AmdEdgeNodes}, IdleWatts: 12, MaxWatts: 45},
+		{NodeSelector: NodeSelector{Name: "arm-edge", NodeNames: ArmEdgeNodes}, IdleWatts: 3, MaxWatts: 7, Curve: []float64{3, 4.2, 5.1, 5.8, 6.4, 7}},
+	}
+	DefaultPowerModel = PowerModel{NodeSelector: NodeSelector{Name: "default"}, IdleWatts: 50, MaxWatts: 150}
+	// EnergyScale 每瓦边际功耗的扣分
+	EnergyScale = 0.05
+	// EnergyMaxUtilization 整合的 SLA 上限：放置后 CPU 使用率超过该值的节点与空闲节点同等对待，不再鼓励整合
+	EnergyMaxUtilization = 0.8
+
+	// NodeCosts 节点小时成本，第一条命中的策略生效，未命中的节点成本为 0（例如自有的边缘硬件）
+	NodeCosts = []NodeCost{
+		{NodeSelector: NodeSelector{Name: "cloud-spot", NodeLabels: map[string]string{NodeSpotLabel: "true"}}, HourlyCost: 0.12, Spot: true},
+		{NodeSelector: NodeSelector{Name: "cloud-on-demand", NodeNames: CloudNodes}, HourlyCost: 0.4},
+	}
+	// CostClassWeights 各成本敏感等级的成本扣分权重
+	CostClassWeights = map[string]float64{
+		CostClassSensitive:   3,
+		CostClassStandard:    1,
+		CostClassInsensitive: 0,
+	}
+	// CostScale 成本扣分的缩放系数：扣分 = CostScale * 等级权重 * 小时成本 * Pod 占节点可分配量的主导份额
+	CostScale = 10.0
+	// SpotBlock 为 true 时不容忍中断的 Pod 不会放在 spot 节点，否则只扣 SpotPenalty
+	SpotBlock   = true
+	SpotPenalty = 5.0
+
+	// ImageLocalityWeight 镜像本地性加分的权重，为 0 时不计算；节点已缓存 Pod 的镜像时按镜像大小和在节点间的
+	// 分布比例加分（与上游 ImageLocality 插件一致），减少大镜像的拉取时间
+	ImageLocalityWeight = 2.0
+	// ImageLocalityMinThreshold、ImageLocalityMaxContainerThreshold 镜像总分的下限和每个容器的上限（字节），
+	// 总分在 [下限, 上限 × 容器数] 间线性归一化为 0~1
+	ImageLocalityMinThreshold          float64 = 23 * (1 << 20)
+	ImageLocalityMaxContainerThreshold float64 = 1000 * (1 << 20)
+	// ImageRefreshInterval 节点镜像缓存（Node.Status.Images）的刷新周期
+	ImageRefreshInterval = time.Minute
+
+	// FallbackMode 兜底策略，取值见 Fallback* 常量
+	FallbackMode = FallbackLeastLoaded
+	// FallbackCPUHeavyThreshold 最小负载模式下，CPU 请求不低于该值（毫核）的 Pod 按 CPU 使用率选择节点
+	FallbackCPUHeavyThreshold float64 = 4000
+	// FallbackMemHeavyThreshold 最小负载模式下，内存请求超过该值（字节）的 Pod 按内存使用率选择节点
+	FallbackMemHeavyThreshold float64 = 10 * (1 << 30)
+	// FallbackOvercommitRatio 超售模式下，实际使用量 + 请求量 允许达到节点容量的倍数
+	FallbackOvercommitRatio = 1.2
+
+	// BalanceResources 均衡打分的资源维度及权重，例如内存密集型集群可将 memory 的权重设为 2，
+	// 数据摄取类负载较多时可加入 network、disk-io 维度，Pod 通过注解声明预期吞吐和 IOPS
+	BalanceResources = []ResourceWeight{
+		{Name: ResourceCPU, Weight: 1},
+		{Name: ResourceMemory, Weight: 1},
+	}
+	// BalanceDispersion 均衡打分的离散度度量方式，取值见 Dispersion* 常量
+	BalanceDispersion = DispersionVariance
+	// ClusterBalanceWeight 集群间均衡的权重（0~1）：离散度 = (1-权重)*节点内离散度 + 权重*各资源使用率在节点间的离散度（与节点内使用同一度量）
+	// 为 0 时只考虑节点内均衡
+	ClusterBalanceWeight = 0.3
+	// BalanceScoreBase、BalanceScoreScale 节点收益 H = BalanceScoreBase - BalanceScoreScale * 离散度
+	BalanceScoreBase  = 10.0
+	BalanceScoreScale = 100.0
+	// DefaultNetworkBandwidth 无法从 node_network_speed_bytes 获取网卡速率时使用的带宽（字节/秒），默认千兆
+	DefaultNetworkBandwidth float64 = 125000000
+	// DefaultDiskIOPS 未命中 DiskIOPSPolicies 的节点磁盘的 IOPS 能力，用于计算 IOPS 比例以及将 Pod 声明的 IOPS 折算为繁忙时间
+	DefaultDiskIOPS float64 = 3000
+	// DiskIOPSPolicies 节点磁盘的 IOPS 能力，第一条命中的策略生效，节点注解 NodeDiskIOPSAnnotation 优先
+	DiskIOPSPolicies = []DiskIOPSPolicy{
+		{NodeSelector: NodeSelector{Name: "arm-edge-sd", NodeNames: ArmEdgeNodes}, IOPS: 300},
+	}
+
+	// ForecastMethod 打分使用的节点使用量预测方法，取值见 Forecast* 常量；过滤仍使用当前值
+	ForecastMethod = ForecastHoltWinters
+	// ForecastWindow 预测使用的历史窗口，ForecastStep 区间查询步长，ForecastHorizon 预测的时间跨度
+	ForecastWindow  = 2 * time.Hour
+	ForecastStep    = time.Minute
+	ForecastHorizon = 10 * time.Minute
+	// ForecastAggregate 将未来 ForecastHorizon 内每一步的预测值汇总为打分用量的方式，取值见 ForecastAggregate* 常量；
+	// ForecastAggregatePercentileValue 为 percentile 方式使用的百分位（0~100）
+	ForecastAggregate                = ForecastAggregateMax
+	ForecastAggregatePercentileValue = 90.0
+	// EWMAAlpha 指数加权移动平均的平滑系数
+	EWMAAlpha = 0.3
+	// HoltWintersAlpha、HoltWintersBeta、HoltWintersGamma 水平、趋势、季节的平滑系数，HoltWintersSeason 周期长度
+	HoltWintersAlpha  = 0.5
+	HoltWintersBeta   = 0.1
+	HoltWintersGamma  = 0.1
+	HoltWintersSeason = 30 * time.Minute
+	// ForecastPercentileValue 百分位预测使用的百分位（0~100）
+	ForecastPercentileValue = 95.0
+
+	// ProfileWindow 工作负载画像统计的历史窗口，ProfileStep 区间查询步长，ProfileInterval 画像刷新间隔
+	ProfileWindow   = 24 * time.Hour
+	ProfileStep     = 5 * time.Minute
+	ProfileInterval = 10 * time.Minute
+	// ProfileMinSamples 画像至少需要的样本数，不足时仍按请求量打分
+	ProfileMinSamples = 12
+	// ProfilePercentile 打分使用的画像百分位，取值 50 或 95
+	ProfilePercentile = 95
+	// ProfileFile 工作负载画像的持久化文件，为空时不持久化
+	ProfileFile = "workload_profiles.json"
+
+	// CPULimitRisk、MemLimitRisk 按 limit 总和计算的超售风险策略。CPU 超用只会被限流，内存超用会触发 OOM，
+	// 因此内存的上限更低、权重更高且超限直接过滤。未设置 limit 的容器按 request 计
+	CPULimitRisk = LimitRiskPolicy{MaxRatio: 3, Block: false, Weight: 1}
+	MemLimitRisk = LimitRiskPolicy{MaxRatio: 1.5, Block: true, Weight: 3}
+	// LimitRiskScale 超售风险扣分的缩放系数
+	LimitRiskScale = 5.0
+
+	// PressurePolicies 节点压力策略。PSI 为 PressureWindow 内的平均等待时间比例，需要 node-exporter 启用 pressure 采集器
+	// （Linux 4.20+），没有压力数据的节点不扣分也不过滤
+	PressurePolicies = []PressurePolicy{
+		{Signal: PressureCPU, Threshold: 0.3, Block: false, Weight: 1},
+		{Signal: PressureMemory, Threshold: 0.2, Block: true, Weight: 2},
+		{Signal: PressureIO, Threshold: 0.3, Block: false, Weight: 1},
+		{Signal: PressureLoad, Threshold: 1.5, Block: false, Weight: 1},
+	}
+	// PressureScale 压力扣分的缩放系数
+	PressureScale = 2.0
+	// PressureWindow 计算 PSI 持续压力的时间窗口（PromQL 区间）
+	PressureWindow = "5m"
+
+	// HealthPolicies 节点健康策略，节点条件与内核信号任一不健康时过滤或扣除健康分
+	HealthPolicies = []HealthPolicy{
+		{Signal: "MemoryPressure", Threshold: 1, Block: true},
+		{Signal: "DiskPressure", Threshold: 1, Block: true},
+		{Signal: "PIDPressure", Threshold: 1, Block: true},
+		{Signal: "NetworkUnavailable", Threshold: 1, Block: true},
+		{Signal: HealthOOMKills, Threshold: 1, Block: false, Penalty: 40},
+		{Signal: HealthReboots, Threshold: 2, Block: false, Penalty: 30},
+	}
+	// HealthWindow 统计 OOM kill、重启次数的时间窗口（PromQL 区间）
+	HealthWindow = "1h"
+	// HealthRefreshInterval 节点健康的刷新周期
+	HealthRefreshInterval = 30 * time.Second
+	// HealthScale 健康扣分的缩放系数：扣分 = HealthScale * (100 - 健康分) / 100
+	HealthScale = 5.0
+	// HealthDebug 为 true 时每次刷新后打印所有节点的健康分及原因
+	HealthDebug = true
+
+	// Prometheus Prometheus 客户端配置
+	Prometheus = PrometheusConfig{
+		Endpoints:    []string{fmt.Sprintf("http://%s:%d", MasterIp, PrometheusPort)},
+		Timeout:      10 * time.Second,
+		Retries:      2,
+		RetryBackoff: 500 * time.Millisecond,
+	}
+
+	// MetricsBackend 监控数据源，取值见 MetricsBackend* 常量
+	MetricsBackend = MetricsBackendPrometheus
+	// StaticMetricsFile static 数据源读取的 JSON 文件
+	StaticMetricsFile = "static_metrics.json"
+
+	// MetricsCacheTTL 监控快照的有效期，MetricsRefreshInterval 后台刷新快照的间隔
+	MetricsCacheTTL        = 15 * time.Second
+	MetricsRefreshInterval = 10 * time.Second
+	// MetricsSnapshotTimeout 获取一份监控快照的总期限，各监控项并发查询，超时未返回的按获取失败处理
+	MetricsSnapshotTimeout = 8 * time.Second
+
+	// MetricsMaxAge 监控样本及节点最近一次采集距今的最大时长，超过视为过期
+	MetricsMaxAge = 2 * time.Minute
+	// MissingMetricsPolicy 节点缺少有效监控数据时的处理方式，取值见 MissingMetrics* 常量
+	MissingMetricsPolicy = MissingMetricsRequestsOnly
+	// LastKnownMaxAge last-known 模式下最近一次有效数据的最大可用时长
+	LastKnownMaxAge = 10 * time.Minute
+
+	// LatencyBackend 时延矩阵数据源，取值见 LatencyBackend* 常量
+	LatencyBackend = LatencyBackendPrometheus
+	// LatencyFile static 数据源读取的 JSON 文件，源节点 -> 目标 -> 往返时延（秒）
+	//	{"edge1": {"edge2": 0.002, "redis.default.svc:6379": 0.015}}
+	LatencyFile = "latency_matrix.json"
+	// LatencyCacheTTL 时延矩阵的有效期
+	LatencyCacheTTL = time.Minute
+	// BlackboxJob blackbox-exporter 的采集任务；以 DaemonSet 部署，LatencySourceLabel 为发起探测的节点，
+	// LatencyTargetLabel 为探测目标
+	BlackboxJob        = "blackbox"
+	LatencySourceLabel = "node"
+	LatencyTargetLabel = "instance"
+	// LatencyFilterUnknown 为 true 时，Pod 设置了最大时延而节点到目标的时延未知的节点被过滤
+	LatencyFilterUnknown = false
+	// LatencyScale、LatencyReference 时延扣分 = LatencyScale * 往返时延 / LatencyReference
+	LatencyScale     = 2.0
+	LatencyReference = 10 * time.Millisecond
+
+	// InstanceMapping Prometheus 序列到节点名称的映射方式，取值见 InstanceMapping* 常量
+	InstanceMapping = InstanceMappingInstance
+	// InstanceLabel label 映射模式下表示节点名称的标签，例如 node、kubernetes_node、nodename
+	InstanceLabel = "node"
+	// NodeGroupBy 节点监控聚合时保留的标签
+	NodeGroupBy = nodeGroupBy()
+	// NodeUnameURL node_uname_info 中 instance 与主机名（nodename）的对应关系，用于 uname 映射模式
+	NodeUnameURL = fmt.Sprintf(`node_uname_info{job="%s"}`, JOB)
+
 	BasicOccupationCpu = map[string]float64{}
 	BasicOccupationMem = map[string]float64{}
 
 	// NodeCpuFreeURL 过去2分钟的CPU空闲率
 	NodeCpuFreeURL = fmt.Sprintf(
-		`avg by (instance)(rate(node_cpu_seconds_total{mode="idle",job="%s"}[2m]))`,
-		JOB,
+		`avg by (%s)(rate(node_cpu_seconds_total{mode="idle",job="%s"}[2m]))`,
+		NodeGroupBy, JOB,
 	)
 
 	// NodeMemFreeURL 内存空闲率
@@ -77,9 +455,9 @@ var (
 
 	// NodeCpuURL Node CPU使用量（毫核心）
 	NodeCpuURL = fmt.Sprintf(
-		`(1 - avg by (instance)(rate(node_cpu_seconds_total{mode="idle",job="%s"}[2m])))*`+
-			`(count(count(node_cpu_seconds_total{job="%s"}) by (cpu,instance)) by (instance))*1000`,
-		JOB, JOB,
+		`(1 - avg by (%[1]s)(rate(node_cpu_seconds_total{mode="idle",job="%[2]s"}[2m])))*`+
+			`(count(count(node_cpu_seconds_total{job="%[2]s"}) by (cpu,%[1]s)) by (%[1]s))*1000`,
+		NodeGroupBy, JOB,
 	)
 
 	// NodeMemURL Node内存使用量（字节）
@@ -88,11 +466,128 @@ var (
 		JOB, JOB,
 	)
 
-	// PodCpuURL 示例：获取指定Pod的CPU使用量（需要传入podName变量）
+	// NodeLastScrapeURL Node最近一次采集的时间戳（秒），用于判断监控数据是否过期
+	NodeLastScrapeURL = fmt.Sprintf(
+		`max by (%s)(timestamp(node_memory_MemTotal_bytes{job="%s"}))`,
+		NodeGroupBy, JOB,
+	)
+
+	// NodeStorageURL Node根文件系统使用量（字节），即临时存储所在分区
+	NodeStorageURL = fmt.Sprintf(
+		`sum by (%s)(node_filesystem_size_bytes{job="%s",mountpoint="/"} - node_filesystem_avail_bytes{job="%s",mountpoint="/"})`,
+		NodeGroupBy, JOB, JOB,
+	)
+
+	// nodeNetBusiestDirection 每块物理网卡收、发方向中较大的吞吐（字节/秒），全双工网卡两个方向各自占用带宽
+	nodeNetBusiestDirection = fmt.Sprintf(
+		`((rate(node_network_receive_bytes_total{job="%[1]s",device!~"%[2]s"}[2m]) > `+
+			`rate(node_network_transmit_bytes_total{job="%[1]s",device!~"%[2]s"}[2m])) or `+
+			`rate(node_network_transmit_bytes_total{job="%[1]s",device!~"%[2]s"}[2m]))`,
+		JOB, VirtualNetDevices,
+	)
+	// NodeNetURL Node物理网卡利用率（0~1）：每块网卡较繁忙方向的吞吐除以该网卡的速率，取最繁忙的网卡；
+	// 没有速率的网卡按 DefaultNetworkBandwidth 计
+	NodeNetURL = fmt.Sprintf(
+		`max by (%[1]s)(%[2]s / ((node_network_speed_bytes{job="%[3]s",device!~"%[4]s"} > 0) or (%[2]s * 0 + %.0[5]f)))`,
+		NodeGroupBy, nodeNetBusiestDirection, JOB, VirtualNetDevices, DefaultNetworkBandwidth,
+	)
+
+	// NodeNetSpeedURL Node物理网卡速率（字节/秒）
+	NodeNetSpeedURL = fmt.Sprintf(
+		`max by (%s)(node_network_speed_bytes{job="%s",device!~"%s"})`,
+		NodeGroupBy, JOB, VirtualNetDevices,
+	)
+
+	// NodeDiskIOURL Node物理磁盘繁忙时间比例（0~1），取最繁忙的磁盘
+	NodeDiskIOURL = fmt.Sprintf(
+		`max by (%s)(rate(node_disk_io_time_seconds_total{job="%s",device=~"%s"}[2m]))`,
+		NodeGroupBy, JOB, PhysicalDiskDevices,
+	)
+
+	// NodeDiskIOPSURL Node物理磁盘读写次数（次/秒）
+	NodeDiskIOPSURL = fmt.Sprintf(
+		`sum by (%s)(rate(node_disk_reads_completed_total{job="%s",device=~"%s"}[2m]) + `+
+			`rate(node_disk_writes_completed_total{job="%s",device=~"%s"}[2m]))`,
+		NodeGroupBy, JOB, PhysicalDiskDevices, JOB, PhysicalDiskDevices,
+	)
+
+	// NodePressureCPUURL、NodePressureMemoryURL、NodePressureIOURL Node在 PressureWindow 内有任务等待 CPU、内存、I/O 的时间比例（0~1）
+	NodePressureCPUURL = fmt.Sprintf(
+		`max by (%s)(rate(node_pressure_cpu_waiting_seconds_total{job="%s"}[%s]))`,
+		NodeGroupBy, JOB, PressureWindow,
+	)
+	NodePressureMemoryURL = fmt.Sprintf(
+		`max by (%s)(rate(node_pressure_memory_waiting_seconds_total{job="%s"}[%s]))`,
+		NodeGroupBy, JOB, PressureWindow,
+	)
+	NodePressureIOURL = fmt.Sprintf(
+		`max by (%s)(rate(node_pressure_io_waiting_seconds_total{job="%s"}[%s]))`,
+		NodeGroupBy, JOB, PressureWindow,
+	)
+
+	// NodeLoadPerCoreURL Node 5 分钟平均负载除以 CPU 核数
+	NodeLoadPerCoreURL = fmt.Sprintf(
+		`max by (%s)(node_load5{job="%s"}) / count by (%s)(node_cpu_seconds_total{job="%s",mode="idle"})`,
+		NodeGroupBy, JOB, NodeGroupBy, JOB,
+	)
+
+	// NodeOOMKillsURL Node在 HealthWindow 内的内核 OOM kill 次数
+	NodeOOMKillsURL = fmt.Sprintf(
+		`max by (%s)(increase(node_vmstat_oom_kill{job="%s"}[%s]))`,
+		NodeGroupBy, JOB, HealthWindow,
+	)
+
+	// NodeRebootsURL Node在 HealthWindow 内的重启次数（启动时间变化次数）
+	NodeRebootsURL = fmt.Sprintf(
+		`max by (%s)(changes(node_boot_time_seconds{job="%s"}[%s]))`,
+		NodeGroupBy, JOB, HealthWindow,
+	)
+
+	// NodeLatencyURL 各节点 blackbox-exporter 到探测目标 5 分钟内的平均探测耗时（秒），ICMP、TCP 探测时近似为往返时延
+	NodeLatencyURL = fmt.Sprintf(
+		`avg by (%s,%s)(avg_over_time(probe_duration_seconds{job="%s"}[5m]))`,
+		LatencySourceLabel, LatencyTargetLabel, BlackboxJob,
+	)
+
+	// AllPodsCpuURL 所有Pod各容器的CPU使用量（毫核），按命名空间/Pod/容器分组
+	AllPodsCpuURL = fmt.Sprintf(
+		`sum by (%s)(rate(container_cpu_usage_seconds_total{job="%s",%s}[2m]))*1000`,
+		PodContainerGroupBy, CadvisorJob, PodContainerFilter,
+	)
+
+	// AllPodsMemURL 所有Pod各容器的内存使用量（字节），按命名空间/Pod/容器分组
+	AllPodsMemURL = fmt.Sprintf(
+		`sum by (%s)(container_memory_usage_bytes{job="%s",%s})`,
+		PodContainerGroupBy, CadvisorJob, PodContainerFilter,
+	)
+
+	// AllPodsCpuRangeURL 所有Pod的CPU使用量（毫核），按命名空间/Pod分组，用于区间查询
+	AllPodsCpuRangeURL = fmt.Sprintf(
+		`sum by (%s,%s)(rate(container_cpu_usage_seconds_total{job="%s",%s}[2m]))*1000`,
+		PodNamespaceLabel, PodNameLabel, CadvisorJob, PodContainerFilter,
+	)
+
+	// AllPodsMemRangeURL 所有Pod的内存使用量（字节），按命名空间/Pod分组，用于区间查询
+	AllPodsMemRangeURL = fmt.Sprintf(
+		`sum by (%s,%s)(container_memory_usage_bytes{job="%s",%s})`,
+		PodNamespaceLabel, PodNameLabel, CadvisorJob, PodContainerFilter,
+	)
+
+	// PodCpuURL 示例：获取指定Pod的CPU使用量（需要依次传入 job、命名空间和 Pod 名称）
 	PodCpuURL = `sum(rate(container_cpu_usage_seconds_total{` +
-		`container_label_io_kubernetes_container_name!="POD",job="%s",container_label_io_kubernetes_pod_name="%s"}[2m]))*1000`
+		`container_label_io_kubernetes_container_name!="POD",job="%s",` +
+		`container_label_io_kubernetes_pod_namespace="%s",container_label_io_kubernetes_pod_name="%s"}[2m]))*1000`
 
-	// PodMemURL 示例：获取指定Pod的内存使用量
+	// PodMemURL 示例：获取指定Pod的内存使用量（需要依次传入 job、命名空间和 Pod 名称）
 	PodMemURL = `container_memory_usage_bytes{` +
-		`container_label_io_kubernetes_container_name!="POD",job="%s",container_label_io_kubernetes_pod_name="%s"}`
+		`container_label_io_kubernetes_container_name!="POD",job="%s",` +
+		`container_label_io_kubernetes_pod_namespace="%s",container_label_io_kubernetes_pod_name="%s"}`
 )
+
+// nodeGroupBy label 映射模式下，聚合时需要额外保留 InstanceLabel
+func nodeGroupBy() string {
+	if InstanceMapping == InstanceMappingLabel {
+		return "instance," + InstanceLabel
+	}
+	return "instance"
+}
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"MBCTG/pkg/definition"
+	"MBCTG/pkg/utils"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nodeActive 判断节点上是否运行着业务 Pod，DaemonSet 和静态 Pod 不计入
+func (cs *CustomScheduler) nodeActive(name string) bool {
+	cs.podsLock.RLock()
+	defer cs.podsLock.RUnlock()
+	for _, pod := range cs.NodePods[name] {
+		k8sPod, ok := pod.K8sPod.(*corev1.Pod)
+		if !ok {
+			return true
+		}
+		if _, mirror := k8sPod.Annotations[corev1.MirrorPodAnnotationKey]; mirror {
+			continue
+		}
+		daemon := false
+		for _, owner := range k8sPod.OwnerReferences {
+			if owner.Kind == "DaemonSet" {
+				daemon = true
+			}
+		}
+		if !daemon {
+			return true
+		}
+	}
+	return false
+}
+
+// nodePower 估算节点当前及放置 Pod 后的功耗（瓦），CPU 按节点性能系数换算；t0 为 nil 时两者相同
+func (cs *CustomScheduler) nodePower(t0 *definition.Pod, c *candidate) (before, after float64) {
+	model, ok := cs.PowerModels[c.node.Name]
+	if !ok || c.node.CapacityCPU <= 0 {
+		return 0, 0
+	}
+	before = utils.NodePower(model, c.cpuPredicted/c.node.CapacityCPU)
+	after = before
+	if t0 != nil {
+		after = utils.NodePower(model, (c.cpuPredicted+normalizeCPU(t0, c).CPURequest)/c.node.CapacityCPU)
+	}
+	return before, after
+}
+
+// energyPenalty 按放置的边际功耗扣分：唤醒没有业务 Pod 的节点需额外计入其空闲功耗（该节点原本可以休眠），
+// 放置后 CPU 使用率超过 definition.EnergyMaxUtilization 的节点同样计入，避免为节能牺牲 SLA
+func (cs *CustomScheduler) energyPenalty(t0 *definition.Pod, c *candidate, d *scoreDetail) float64 {
+	if !definition.EnergyMode {
+		return 0
+	}
+	before, after := cs.nodePower(t0, c)
+	marginal := after - before
+	model := cs.PowerModels[c.node.Name]
+	switch {
+	case model == nil:
+		// 没有功耗模型的节点边际功耗为 0
+	case !cs.nodeActive(c.node.Name):
+		marginal += model.IdleWatts
+		d.explain("唤醒空闲节点 +%.1fW", model.IdleWatts)
+	case (c.cpuPredicted+normalizeCPU(t0, c).CPURequest)/c.node.CapacityCPU > definition.EnergyMaxUtilization:
+		marginal += model.IdleWatts
+		d.explain("超过整合上限 %.2f +%.1fW", definition.EnergyMaxUtilization, model.IdleWatts)
+	}
+	penalty := definition.EnergyScale * marginal
+	d.explain("边际功耗 %.1fW，扣分 %.2f", marginal, penalty)
+	return penalty
+}
+
+// reportPower 打印决策前后的集群估计功耗，nodes 为所有有使用量数据的节点（不论是否满足该 Pod 的约束）
+func (cs *CustomScheduler) reportPower(t0 *definition.Pod, nodes []*candidate, decision *Decision) {
+	var before, after float64
+	for _, c := range nodes {
+		var pod *definition.Pod
+		if decision.Node != nil && c.node.Name == decision.Node.ObjectMeta.Name {
+			pod = t0
+		}
+		b, a := cs.nodePower(pod, c)
+		before += b
+		after += a
+	}
+	fmt.Printf("集群估计功耗：%.1fW -> %.1fW（%+.1fW）\n", before, after, after-before)
+}
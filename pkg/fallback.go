@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"MBCTG/pkg/definition"
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"math"
+)
+
+// fallback 没有节点通过过滤时，按 definition.FallbackMode 处理；只在候选节点中选择，且始终满足 kubelet 准入
+func (cs *CustomScheduler) fallback(t0 *definition.Pod, candidates []*candidate) *Decision {
+	switch definition.FallbackMode {
+	case definition.FallbackStrict:
+		return &Decision{
+			Fallback: definition.FallbackStrict,
+			Reason:   fmt.Sprintf("严格模式：%d 个候选节点均不满足资源需求", len(candidates)),
+		}
+	case definition.FallbackLeastLoaded:
+		return cs.fallbackLeastLoaded(t0, candidates)
+	case definition.FallbackOvercommit:
+		return cs.fallbackOvercommit(t0, candidates)
+	default:
+		return &Decision{
+			Fallback: definition.FallbackMode,
+			Reason:   fmt.Sprintf("不支持的兜底策略: %s", definition.FallbackMode),
+		}
+	}
+}
+
+// fallbackLeastLoaded 最小负载模式：CPU 密集型 Pod 选 CPU 使用率最低的节点，内存密集型选内存使用率最低的节点，其余选两者之和最低的节点
+func (cs *CustomScheduler) fallbackLeastLoaded(t0 *definition.Pod, candidates []*candidate) *Decision {
+	var load func(c *candidate) float64
+	var basis string
+	switch {
+	case t0.CPURequest >= definition.FallbackCPUHeavyThreshold:
+		basis = "CPU 使用率"
+		load = func(c *candidate) float64 { return usageRate(c.cpuUsed, c.node.CapacityCPU) }
+	case t0.MemoryRequest > definition.FallbackMemHeavyThreshold:
+		basis = "内存使用率"
+		load = func(c *candidate) float64 { return usageRate(c.memUsed, c.node.CapacityMemory) }
+	default:
+		basis = "CPU 与内存使用率之和"
+		load = func(c *candidate) float64 {
+			return usageRate(c.cpuUsed, c.node.CapacityCPU) + usageRate(c.memUsed, c.node.CapacityMemory)
+		}
+	}
+
+	var chosen *candidate
+	minVal := math.MaxFloat64
+	for _, c := range candidates {
+		if ok, _ := cs.fitsAllocatable(t0, c.node, nil); !ok {
+			continue
+		}
+		if val := load(c); val < minVal {
+			minVal = val
+			chosen = c
+		}
+	}
+	if chosen == nil {
+		return &Decision{
+			Fallback: definition.FallbackLeastLoaded,
+			Reason:   "最小负载模式：没有节点满足 kubelet 准入（请求量超过可分配量）",
+		}
+	}
+	return &Decision{
+		Node:     chosen.k8sNode,
+		Fallback: definition.FallbackLeastLoaded,
+		Reason:   fmt.Sprintf("最小负载模式：按%s选择，%s 为 %.2f", basis, chosen.node.Name, minVal),
+	}
+}
+
+// fallbackOvercommit 超售模式：在实际使用量不超过容量 definition.FallbackOvercommitRatio 倍的节点中，选择放置后使用率最高的资源最低的节点
+func (cs *CustomScheduler) fallbackOvercommit(t0 *definition.Pod, candidates []*candidate) *Decision {
+	var chosen *candidate
+	minVal := math.MaxFloat64
+	for _, c := range candidates {
+		if ok, _ := cs.fitsAllocatable(t0, c.node, nil); !ok {
+			continue
+		}
+		if ok, _ := fitsUsage(t0, c, nil, definition.FallbackOvercommitRatio); !ok {
+			continue
+		}
+		cpuRate := usageRate(c.cpuUsed+t0.CPURequest, c.node.CapacityCPU)
+		memRate := usageRate(c.memUsed+t0.MemoryRequest, c.node.CapacityMemory)
+		if val := math.Max(cpuRate, memRate); val < minVal {
+			minVal = val
+			chosen = c
+		}
+	}
+	if chosen == nil {
+		return &Decision{
+			Fallback: definition.FallbackOvercommit,
+			Reason:   fmt.Sprintf("超售模式：没有节点在 %.2f 倍容量内满足资源需求", definition.FallbackOvercommitRatio),
+		}
+	}
+	return &Decision{
+		Node:     chosen.k8sNode,
+		Fallback: definition.FallbackOvercommit,
+		Reason: fmt.Sprintf("超售模式：%s 放置后最高使用率 %.2f（上限 %.2f）",
+			chosen.node.Name, minVal, definition.FallbackOvercommitRatio),
+	}
+}
+
+// usageRate 返回使用率；容量未知（不大于 0）的节点按满载 1 计，避免除零得到 +Inf/NaN 打乱排序
+func usageRate(used, capacity float64) float64 {
+	if capacity <= 0 {
+		return 1
+	}
+	return used / capacity
+}
+
+// markUnschedulable 将 Pod 的 PodScheduled 条件设置为 Unschedulable 并记录原因
+func (cs *CustomScheduler) markUnschedulable(k8sPod *corev1.Pod, reason string) {
+	pod := k8sPod.DeepCopy()
+	condition := corev1.PodCondition{
+		Type:               corev1.PodScheduled,
+		Status:             corev1.ConditionFalse,
+		Reason:             corev1.PodReasonUnschedulable,
+		Message:            reason,
+		LastTransitionTime: metav1.Now(),
+	}
+	replaced := false
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == corev1.PodScheduled {
+			pod.Status.Conditions[i] = condition
+			replaced = true
+		}
+	}
+	if !replaced {
+		pod.Status.Conditions = append(pod.Status.Conditions, condition)
+	}
+	_, err := cs.Clientset.CoreV1().Pods(pod.ObjectMeta.Namespace).UpdateStatus(context.TODO(), pod, metav1.UpdateOptions{})
+	if err != nil {
+		fmt.Printf("更新 Pod %s 的 Unschedulable 状态出错: %v\n", pod.ObjectMeta.Name, err)
+	}
+}
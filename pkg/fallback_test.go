@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"MBCTG/pkg/definition"
+	"testing"
+)
+
+// unknownCapacity 构造容量尚未获取（为 0）但可分配量充足的候选节点
+func unknownCapacity(name string, cpu, mem float64) *candidate {
+	c := testCandidate(name, cpu, mem)
+	c.node.CapacityCPU, c.node.CapacityMemory = 0, 0
+	return c
+}
+
+func TestFallback(t *testing.T) {
+	defer func(mode string, cpuHeavy, memHeavy, ratio float64) {
+		definition.FallbackMode = mode
+		definition.FallbackCPUHeavyThreshold, definition.FallbackMemHeavyThreshold = cpuHeavy, memHeavy
+		definition.FallbackOvercommitRatio = ratio
+	}(definition.FallbackMode, definition.FallbackCPUHeavyThreshold, definition.FallbackMemHeavyThreshold,
+		definition.FallbackOvercommitRatio)
+	definition.FallbackCPUHeavyThreshold = 500
+	definition.FallbackMemHeavyThreshold = 500
+	definition.FallbackOvercommitRatio = 1.2
+
+	tests := []struct {
+		name       string
+		mode       string
+		cpu, mem   float64 // 待调度 Pod 的请求量
+		candidates []*candidate
+		want       string // 期望选中的节点，为空表示不可调度
+	}{
+		{
+			name:       "严格模式不选择节点",
+			mode:       definition.FallbackStrict,
+			cpu:        100,
+			candidates: []*candidate{testCandidate("a", 0, 0)},
+		},
+		{
+			name:       "最小负载模式按使用率之和选择",
+			mode:       definition.FallbackLeastLoaded,
+			cpu:        100,
+			mem:        100,
+			candidates: []*candidate{testCandidate("a", 300, 300), testCandidate("b", 100, 400), testCandidate("c", 400, 0)},
+			want:       "c",
+		},
+		{
+			name:       "最小负载模式 CPU 密集型按 CPU 使用率选择",
+			mode:       definition.FallbackLeastLoaded,
+			cpu:        500,
+			candidates: []*candidate{testCandidate("a", 100, 900), testCandidate("b", 300, 0)},
+			want:       "a",
+		},
+		{
+			name:       "最小负载模式内存密集型按内存使用率选择",
+			mode:       definition.FallbackLeastLoaded,
+			mem:        600,
+			candidates: []*candidate{testCandidate("a", 0, 300), testCandidate("b", 900, 100)},
+			want:       "b",
+		},
+		{
+			name:       "最小负载模式容量未知的节点按满载计",
+			mode:       definition.FallbackLeastLoaded,
+			cpu:        500,
+			candidates: []*candidate{unknownCapacity("a", 100, 0), testCandidate("b", 900, 0)},
+			want:       "b",
+		},
+		{
+			name:       "最小负载模式跳过不满足准入的节点",
+			mode:       definition.FallbackLeastLoaded,
+			cpu:        2000,
+			candidates: []*candidate{testCandidate("a", 0, 0)},
+		},
+		{
+			name:       "超售模式选择放置后最高使用率最低的节点",
+			mode:       definition.FallbackOvercommit,
+			cpu:        300,
+			candidates: []*candidate{testCandidate("a", 950, 0), testCandidate("b", 800, 100), testCandidate("c", 600, 900)},
+			want:       "c",
+		},
+		{
+			name:       "超售模式超出比例时不可调度",
+			mode:       definition.FallbackOvercommit,
+			cpu:        300,
+			candidates: []*candidate{testCandidate("a", 950, 0), unknownCapacity("b", 0, 0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition.FallbackMode = tt.mode
+			cs := &CustomScheduler{}
+			t0 := &definition.Pod{Name: "p", CPURequest: tt.cpu, MemoryRequest: tt.mem}
+			decision := cs.fallback(t0, tt.candidates)
+			if decision.Fallback != tt.mode {
+				t.Errorf("fallback() Fallback = %q, want %q", decision.Fallback, tt.mode)
+			}
+			var got string
+			if decision.Node != nil {
+				got = decision.Node.Name
+			}
+			if got != tt.want {
+				t.Errorf("fallback() Node = %q, want %q (%s)", got, tt.want, decision.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"MBCTG/pkg/definition"
+	"MBCTG/pkg/utils"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"strings"
+	"time"
+)
+
+// nodeFitsResources 检查 Pod 能否放入节点，需同时满足两类约束：
+// 1. 已放置 Pod 的请求量之和 + 新 Pod 请求量 不超过 Allocatable - 预留量（与 kubelet 准入一致，避免 OutOfcpu/OutOfmemory）
+// 2. 实际使用量 + 新 Pod 请求量 不超过 Capacity - 预留量（保证真实负载仍有余量）
+// 准入检查始终使用 Pod 的原始请求量，只有实际余量检查按 CPU 性能系数换算
+func (cs *CustomScheduler) nodeFitsResources(t0 *definition.Pod, c *candidate) (bool, string) {
+	if ok, reason := cs.fitsAllocatable(t0, c.node, c.reservation); !ok {
+		return false, reason
+	}
+	return fitsUsage(normalizeCPU(t0, c), c, c.reservation, 1)
+}
+
+// fitsHardConstraints 检查配置为硬性阻断的约束。不满足的节点不会进入候选，兜底策略也不会选择；
+// 兜底策略只放宽预留和实际余量
+func (cs *CustomScheduler) fitsHardConstraints(t0 *definition.Pod, c *candidate) (bool, string) {
+	if ok, reason := fitsArchitecture(t0, c.k8sNode); !ok {
+		return false, reason
+	}
+	if ok, reason := cs.fitsLimitRisk(t0, c); !ok {
+		return false, reason
+	}
+	if ok, reason := fitsLatency(t0, c); !ok {
+		return false, reason
+	}
+	if ok, reason := fitsInterruption(t0, c); !ok {
+		return false, reason
+	}
+	if ok, reason := fitsPressure(c); !ok {
+		return false, reason
+	}
+	return fitsHealth(c)
+}
+
+// eligibleCandidates 返回满足硬性约束的候选节点，打印被过滤的原因
+func (cs *CustomScheduler) eligibleCandidates(t0 *definition.Pod, candidates []*candidate) []*candidate {
+	var eligible []*candidate
+	for _, c := range candidates {
+		if ok, reason := cs.fitsHardConstraints(t0, c); !ok {
+			fmt.Printf("%s被过滤：%s\n", c.node.Name, reason)
+			continue
+		}
+		eligible = append(eligible, c)
+	}
+	return eligible
+}
+
+// fitsArchitecture 检查节点架构是否在 Pod 支持的架构中
+func fitsArchitecture(t0 *definition.Pod, n *corev1.Node) (bool, string) {
+	if t0.Architectures == nil {
+		return true, ""
+	}
+	arch := utils.NodeArchitecture(n)
+	if utils.Contains(t0.Architectures, arch) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("节点架构 %s 不在 Pod 支持的架构 %v 中", arch, t0.Architectures)
+}
+
+// normalizeCPU 性能敏感的 Pod 在性能系数为 f 的节点上需要 请求量/f 毫核，等价于将节点容量和使用量换算为基准计算单位；
+// 其他 Pod 原样返回。kubelet 准入和 limit 超售按 spec 中的请求量计算，因此只用于实际余量检查和使用率、功耗估算
+func normalizeCPU(t0 *definition.Pod, c *candidate) *definition.Pod {
+	if !t0.PerformanceSensitive || c.cpuFactor == 1 || c.cpuFactor <= 0 {
+		return t0
+	}
+	normalized := *t0
+	normalized.CPURequest /= c.cpuFactor
+	normalized.CPULimits /= c.cpuFactor
+	return &normalized
+}
+
+// limitRatios 返回 Pod 放置后节点 CPU、内存的 limit 总和与 Allocatable 之比
+func (cs *CustomScheduler) limitRatios(t0 *definition.Pod, node *definition.Node) (cpuRatio, memRatio float64) {
+	requested := cs.nodeRequested(node.Name)
+	if node.AllocatableCPU > 0 {
+		cpuRatio = (requested.cpuLimits + t0.CPULimits) / node.AllocatableCPU
+	}
+	if node.AllocatableMemory > 0 {
+		memRatio = (requested.memLimits + t0.MemoryLimits) / node.AllocatableMemory
+	}
+	return cpuRatio, memRatio
+}
+
+// fitsLimitRisk 检查 limit 超售比例，只有配置为 Block 的资源超过上限时才过滤
+func (cs *CustomScheduler) fitsLimitRisk(t0 *definition.Pod, c *candidate) (bool, string) {
+	cpuRatio, memRatio := cs.limitRatios(t0, c.node)
+	if definition.MemLimitRisk.Block && memRatio > definition.MemLimitRisk.MaxRatio {
+		return false, fmt.Sprintf("内存 limit 超售比例 %.2f 超过上限 %.2f", memRatio, definition.MemLimitRisk.MaxRatio)
+	}
+	if definition.CPULimitRisk.Block && cpuRatio > definition.CPULimitRisk.MaxRatio {
+		return false, fmt.Sprintf("CPU limit 超售比例 %.2f 超过上限 %.2f", cpuRatio, definition.CPULimitRisk.MaxRatio)
+	}
+	return true, ""
+}
+
+// fitsPressure 检查节点持续压力，只有配置为 Block 的信号超过阈值时才过滤
+func fitsPressure(c *candidate) (bool, string) {
+	for _, policy := range definition.PressurePolicies {
+		value, ok := c.pressure[policy.Signal]
+		if policy.Block && ok && value > policy.Threshold {
+			return false, fmt.Sprintf("%s 持续压力 %.2f 超过阈值 %.2f", policy.Signal, value, policy.Threshold)
+		}
+	}
+	return true, ""
+}
+
+// fitsLatency 检查节点到 Pod 时延目标的往返时延是否在预算内
+func fitsLatency(t0 *definition.Pod, c *candidate) (bool, string) {
+	if t0.MaxRTT <= 0 {
+		return true, ""
+	}
+	if !c.hasRTT {
+		if definition.LatencyFilterUnknown {
+			return false, fmt.Sprintf("到 %s 的时延未知", t0.LatencyTarget)
+		}
+		return true, ""
+	}
+	if rtt := time.Duration(c.rtt * float64(time.Second)); rtt > t0.MaxRTT {
+		return false, fmt.Sprintf("到 %s 的时延 %v 超过上限 %v", t0.LatencyTarget, rtt.Round(time.Microsecond), t0.MaxRTT)
+	}
+	return true, ""
+}
+
+// fitsInterruption definition.SpotBlock 为 true 时，不容忍中断的 Pod 不能放在 spot 节点
+func fitsInterruption(t0 *definition.Pod, c *candidate) (bool, string) {
+	if definition.SpotBlock && c.cost.spot && !t0.InterruptionTolerant {
+		return false, "spot 节点，Pod 不容忍中断"
+	}
+	return true, ""
+}
+
+// fitsHealth 过滤存在 Block 健康信号的节点
+func fitsHealth(c *candidate) (bool, string) {
+	if c.health != nil && c.health.Blocked {
+		return false, fmt.Sprintf("节点不健康 %s", strings.Join(c.health.Reasons, " "))
+	}
+	return true, ""
+}
+
+// fitsAllocatable 检查请求量约束，reservation 为 nil 时只按 kubelet 准入检查
+func (cs *CustomScheduler) fitsAllocatable(t0 *definition.Pod, node *definition.Node, reservation *definition.Reservation) (bool, string) {
+	if reservation == nil {
+		reservation = &definition.Reservation{}
+	}
+	requested := cs.nodeRequested(node.Name)
+
+	if requested.cpu+t0.CPURequest > node.AllocatableCPU-reservation.CPU {
+		return false, fmt.Sprintf("CPU 请求量不足（已请求 %.0fm + %.0fm > 可分配 %.0fm - 预留 %.0fm）",
+			requested.cpu, t0.CPURequest, node.AllocatableCPU, reservation.CPU)
+	}
+	if requested.mem+t0.MemoryRequest > node.AllocatableMemory-reservation.Memory {
+		return false, fmt.Sprintf("内存请求量不足（已请求 %.2fGB + %.2fGB > 可分配 %.2fGB - 预留 %.2fGB）",
+			requested.mem/(1<<30), t0.MemoryRequest/(1<<30), node.AllocatableMemory/(1<<30), reservation.Memory/(1<<30))
+	}
+	if t0.StorageRequest > 0 && requested.storage+t0.StorageRequest > node.AllocatableStorage {
+		return false, fmt.Sprintf("临时存储请求量不足（已请求 %.2fGB + %.2fGB > 可分配 %.2fGB）",
+			requested.storage/(1<<30), t0.StorageRequest/(1<<30), node.AllocatableStorage/(1<<30))
+	}
+	if node.AllocatablePods > 0 && requested.pods+1 > node.AllocatablePods-reservation.Pods {
+		return false, fmt.Sprintf("Pod 数已满（%.0f/%.0f，预留 %.0f）", requested.pods, node.AllocatablePods, reservation.Pods)
+	}
+	return true, ""
+}
+
+// fitsUsage 检查实际使用量约束，ratio 为容量的超售比例（1 表示不超售），reservation 为 nil 时不考虑预留
+func fitsUsage(t0 *definition.Pod, c *candidate, reservation *definition.Reservation, ratio float64) (bool, string) {
+	if reservation == nil {
+		reservation = &definition.Reservation{}
+	}
+	cpuLimit := c.node.CapacityCPU*ratio - reservation.CPU
+	memLimit := c.node.CapacityMemory*ratio - reservation.Memory
+	if c.cpuUsed+t0.CPURequest > cpuLimit {
+		return false, fmt.Sprintf("CPU 实际余量不足（使用 %.0fm + %.0fm > 上限 %.0fm）",
+			c.cpuUsed, t0.CPURequest, cpuLimit)
+	}
+	if c.memUsed+t0.MemoryRequest > memLimit {
+		return false, fmt.Sprintf("内存实际余量不足（使用 %.2fGB + %.2fGB > 上限 %.2fGB）",
+			c.memUsed/(1<<30), t0.MemoryRequest/(1<<30), memLimit/(1<<30))
+	}
+	return true, ""
+}
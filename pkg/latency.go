@@ -0,0 +1,40 @@
+package pkg
+
+import (
+	"MBCTG/pkg/definition"
+	"MBCTG/pkg/utils"
+	"fmt"
+	"math"
+)
+
+// measureLatency 为候选节点填充到 Pod 时延目标的往返时延；目标为边缘站点时取到该站点最近节点的时延，站点内节点为 0
+func (cs *CustomScheduler) measureLatency(t0 *definition.Pod, candidates []*candidate) {
+	if t0.LatencyTarget == "" {
+		return
+	}
+	matrix, err := utils.GetLatencyMatrix()
+	if err != nil {
+		fmt.Printf("获取时延矩阵错误: %v\n", err)
+	}
+	var siteNodes []string
+	for _, n := range cs.K8sNodes {
+		if n.Labels[definition.EdgeSiteLabel] == t0.LatencyTarget {
+			siteNodes = append(siteNodes, n.ObjectMeta.Name)
+		}
+	}
+	for _, c := range candidates {
+		if rtt, ok := utils.NodeRTT(matrix, c.node.Name, t0.LatencyTarget); ok {
+			c.rtt, c.hasRTT = rtt, true
+			continue
+		}
+		best := math.Inf(1)
+		for _, name := range siteNodes {
+			if rtt, ok := utils.NodeRTT(matrix, c.node.Name, name); ok {
+				best = math.Min(best, rtt)
+			}
+		}
+		if !math.IsInf(best, 1) {
+			c.rtt, c.hasRTT = best, true
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package pkg
+
+import (
+	"MBCTG/pkg/definition"
+	"MBCTG/pkg/utils"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"math"
+	"strings"
+)
+
+// scoreDetail 节点收益 H 及各项打分说明
+type scoreDetail struct {
+	node  string
+	score float64
+	items []string
+}
+
+// explain 追加一条打分说明
+func (d *scoreDetail) explain(format string, a ...interface{}) {
+	d.items = append(d.items, fmt.Sprintf(format, a...))
+}
+
+func (d *scoreDetail) String() string {
+	return fmt.Sprintf("%s收益：%f（%s）", d.node, d.score, strings.Join(d.items, "；"))
+}
+
+// balanceMonitor 均衡打分需要 resource 维度时从快照获取对应的节点监控数据，未配置或获取失败时返回空 map
+func balanceMonitor(snapshot *utils.MetricsSnapshot, resource, req string) map[string]float64 {
+	enabled := false
+	for _, rw := range definition.BalanceResources {
+		if rw.Name == resource && rw.Weight > 0 {
+			enabled = true
+		}
+	}
+	if !enabled {
+		return map[string]float64{}
+	}
+	monitor, err := snapshot.NodeValues(req)
+	if err != nil {
+		fmt.Printf("获取节点 %s 监控数据错误: %v\n", req, err)
+		return map[string]float64{}
+	}
+	return monitor
+}
+
+// predictFootprint 返回用于打分的 Pod：有工作负载画像时以画像的 definition.ProfilePercentile 用量代替请求量，否则原样返回
+func (cs *CustomScheduler) predictFootprint(t0 *definition.Pod) *definition.Pod {
+	k8sPod, ok := t0.K8sPod.(*corev1.Pod)
+	if cs.Profiler == nil || !ok {
+		return t0
+	}
+	workload := utils.GetPodWorkload(k8sPod)
+	profile, ok := cs.Profiler.Get(workload)
+	if !ok {
+		return t0
+	}
+	scored := *t0
+	scored.CPURequest, scored.MemoryRequest = profile.CPUP95, profile.MemP95
+	if definition.ProfilePercentile == 50 {
+		scored.CPURequest, scored.MemoryRequest = profile.CPUP50, profile.MemP50
+	}
+	fmt.Printf("%s 按工作负载 %s 的 p%d 画像打分：CPU %.0fm（请求 %.0fm），内存 %.2fGB（请求 %.2fGB）\n",
+		t0.Name, workload, definition.ProfilePercentile, scored.CPURequest, t0.CPURequest,
+		scored.MemoryRequest/(1<<30), t0.MemoryRequest/(1<<30))
+	return &scored
+}
+
+// utilization 计算 Pod 放置到节点后某一资源维度的使用率，CPU 按节点性能系数换算；t0 为 nil 时计算当前使用率，
+// 无法计算时返回 false
+func (cs *CustomScheduler) utilization(t0 *definition.Pod, c *candidate, resource string) (float64, bool) {
+	var podCount float64 = 1
+	if t0 == nil {
+		t0 = &definition.Pod{}
+		podCount = 0
+	}
+	t0 = normalizeCPU(t0, c)
+	switch resource {
+	case definition.ResourceCPU:
+		return (c.cpuPredicted + t0.CPURequest) / c.node.CapacityCPU, c.node.CapacityCPU > 0
+	case definition.ResourceMemory:
+		return (c.memPredicted + t0.MemoryRequest) / c.node.CapacityMemory, c.node.CapacityMemory > 0
+	case definition.ResourceStorage:
+		if c.node.CapacityStorage <= 0 {
+			return 0, false
+		}
+		if c.hasStorage {
+			return (c.storageUsed + t0.StorageRequest) / c.node.CapacityStorage, true
+		}
+		// 没有文件系统监控数据时按请求量估算
+		requested := cs.nodeRequested(c.node.Name)
+		return (requested.storage + t0.StorageRequest) / c.node.AllocatableStorage, c.node.AllocatableStorage > 0
+	case definition.ResourceNetwork:
+		if !c.hasNet || c.netCapacity <= 0 {
+			return 0, false
+		}
+		// Pod 声明的吞吐按网卡带宽折算为利用率
+		return c.netBusy + t0.NetBandwidth/c.netCapacity, true
+	case definition.ResourceDiskIO:
+		if !c.hasDisk || c.diskCapacity <= 0 {
+			return 0, false
+		}
+		// Pod 声明的 IOPS 按节点磁盘的 IOPS 能力折算为繁忙时间
+		added := t0.DiskIOPS / c.diskCapacity
+		return math.Max(c.diskBusy+added, c.diskIOPS/c.diskCapacity+added), true
+	case definition.ResourcePods:
+		if c.node.AllocatablePods <= 0 {
+			return 0, false
+		}
+		requested := cs.nodeRequested(c.node.Name)
+		return (requested.pods + podCount) / c.node.AllocatablePods, true
+	}
+	return 0, false
+}
+
+// weightedDispersion 按 definition.BalanceDispersion 计算加权离散度
+func weightedDispersion(rates, weights []float64) float64 {
+	var sumW, mean float64
+	for i, r := range rates {
+		sumW += weights[i]
+		mean += weights[i] * r
+	}
+	if sumW == 0 {
+		return 0
+	}
+	mean /= sumW
+	var variance float64
+	for i, r := range rates {
+		variance += weights[i] * math.Pow(r-mean, 2)
+	}
+	variance /= sumW
+
+	switch definition.BalanceDispersion {
+	case definition.DispersionStdDev:
+		return math.Sqrt(variance)
+	case definition.DispersionCV:
+		if mean == 0 {
+			return 0
+		}
+		return math.Sqrt(variance) / mean
+	case definition.DispersionMaxMin:
+		// 各维度相对加权均值的偏差按权重（相对平均权重）放大后取极差，权重全为 1 时即使用率的极差
+		avgW := sumW / float64(len(rates))
+		hi, lo := math.Inf(-1), math.Inf(1)
+		for i, r := range rates {
+			dev := weights[i] / avgW * (r - mean)
+			hi = math.Max(hi, dev)
+			lo = math.Min(lo, dev)
+		}
+		return hi - lo
+	default:
+		return variance
+	}
+}
+
+// clusterDispersion 计算 Pod 放置到 target 后，各资源使用率在 candidates（所有有使用量数据的节点）间的离散度，
+// 度量方式与节点内离散度相同（definition.BalanceDispersion），按 definition.BalanceResources 的权重加权平均
+func (cs *CustomScheduler) clusterDispersion(t0 *definition.Pod, target *candidate, candidates []*candidate) float64 {
+	var sumW, sum float64
+	for _, rw := range definition.BalanceResources {
+		if rw.Weight <= 0 {
+			continue
+		}
+		var rates []float64
+		for _, c := range candidates {
+			pod := t0
+			if c != target {
+				pod = nil
+			}
+			if rate, ok := cs.utilization(pod, c, rw.Name); ok {
+				rates = append(rates, rate)
+			}
+		}
+		if len(rates) < 2 {
+			continue
+		}
+		ones := make([]float64, len(rates))
+		for i := range ones {
+			ones[i] = 1
+		}
+		sum += rw.Weight * weightedDispersion(rates, ones)
+		sumW += rw.Weight
+	}
+	if sumW == 0 {
+		return 0
+	}
+	return sum / sumW
+}
+
+// limitRiskPenalty 按 limit 总和超出 Allocatable 的部分计算超售风险扣分
+func (cs *CustomScheduler) limitRiskPenalty(t0 *definition.Pod, c *candidate, d *scoreDetail) float64 {
+	cpuRatio, memRatio := cs.limitRatios(t0, c.node)
+	penalty := definition.CPULimitRisk.Weight*math.Max(0, cpuRatio-1) +
+		definition.MemLimitRisk.Weight*math.Max(0, memRatio-1)
+	penalty *= definition.LimitRiskScale
+	if penalty > 0 {
+		d.explain("limit 超售 CPU %.2f 内存 %.2f，扣分 %.2f", cpuRatio, memRatio, penalty)
+	}
+	return penalty
+}
+
+// pressurePenalty 按节点压力信号相对阈值的比例计算干扰扣分，超过阈值的节点扣分更多
+func pressurePenalty(c *candidate, d *scoreDetail) float64 {
+	var penalty float64
+	var parts []string
+	for _, policy := range definition.PressurePolicies {
+		value, ok := c.pressure[policy.Signal]
+		if !ok || policy.Weight <= 0 || policy.Threshold <= 0 {
+			continue
+		}
+		penalty += policy.Weight * value / policy.Threshold
+		parts = append(parts, fmt.Sprintf("%s %.2f", policy.Signal, value))
+	}
+	penalty *= definition.PressureScale
+	if penalty > 0 {
+		d.explain("节点压力 %s，扣分 %.2f", strings.Join(parts, " "), penalty)
+	}
+	return penalty
+}
+
+// healthPenalty 按节点健康分计算扣分
+func healthPenalty(c *candidate, d *scoreDetail) float64 {
+	if c.health == nil || c.health.Score >= 100 {
+		return 0
+	}
+	penalty := definition.HealthScale * (100 - c.health.Score) / 100
+	d.explain("健康分 %.0f %s，扣分 %.2f", c.health.Score, strings.Join(c.health.Reasons, " "), penalty)
+	return penalty
+}
+
+// latencyPenalty 按节点到 Pod 时延目标的往返时延扣分，时延未知时不扣分
+func latencyPenalty(t0 *definition.Pod, c *candidate, d *scoreDetail) float64 {
+	if t0.LatencyTarget == "" || !c.hasRTT || definition.LatencyReference <= 0 {
+		return 0
+	}
+	penalty := definition.LatencyScale * c.rtt / definition.LatencyReference.Seconds()
+	d.explain("到 %s 时延 %.1fms，扣分 %.2f", t0.LatencyTarget, c.rtt*1000, penalty)
+	return penalty
+}
+
+// costPenalty 按 Pod 占用节点剩余容量的边际成本扣分：小时成本 × Pod 在 CPU、内存中占可分配量的较大份额，
+// 再乘以 Pod 成本等级的权重；不容忍中断的 Pod 放在 spot 节点时额外扣分
+func costPenalty(t0 *definition.Pod, c *candidate, d *scoreDetail) float64 {
+	var penalty float64
+	if weight := definition.CostClassWeights[t0.CostClass]; weight > 0 && c.cost.hourly > 0 {
+		var share float64
+		if c.node.AllocatableCPU > 0 {
+			share = t0.CPURequest / c.node.AllocatableCPU
+		}
+		if c.node.AllocatableMemory > 0 {
+			share = math.Max(share, t0.MemoryRequest/c.node.AllocatableMemory)
+		}
+		marginal := c.cost.hourly * share
+		penalty += definition.CostScale * weight * marginal
+		d.explain("边际成本 %.4f/小时（节点 %.2f/小时，等级 %s）", marginal, c.cost.hourly, t0.CostClass)
+	}
+	if c.cost.spot && !t0.InterruptionTolerant {
+		penalty += definition.SpotPenalty
+		d.explain("spot 节点，Pod 不容忍中断")
+	}
+	if penalty > 0 {
+		d.explain("成本扣分 %.2f", penalty)
+	}
+	return penalty
+}
+
+// imageLocalityBonus 按节点已缓存的 Pod 镜像加分，镜像总分在 [ImageLocalityMinThreshold,
+// ImageLocalityMaxContainerThreshold × 容器数] 间归一化后乘以 definition.ImageLocalityWeight
+func (cs *CustomScheduler) imageLocalityBonus(t0 *definition.Pod, c *candidate, d *scoreDetail) float64 {
+	k8sPod, ok := t0.K8sPod.(*corev1.Pod)
+	if definition.ImageLocalityWeight <= 0 || !ok {
+		return 0
+	}
+	sum := cs.Images.ImageScore(c.node.Name, k8sPod)
+	containers := len(k8sPod.Spec.InitContainers) + len(k8sPod.Spec.Containers)
+	minThreshold := definition.ImageLocalityMinThreshold
+	maxThreshold := definition.ImageLocalityMaxContainerThreshold * float64(containers)
+	if sum <= minThreshold || maxThreshold <= minThreshold {
+		return 0
+	}
+	sum = math.Min(sum, maxThreshold)
+	bonus := definition.ImageLocalityWeight * (sum - minThreshold) / (maxThreshold - minThreshold)
+	d.explain("已缓存镜像 %.0fMB（按分布比例），加分 %.2f", sum/(1<<20), bonus)
+	return bonus
+}
+
+// scoreNode 计算 Pod 放置到节点后的收益 H：节点内各资源使用率的加权离散度与集群节点间使用率的离散度
+// （同一度量方式）按 definition.ClusterBalanceWeight 混合，越小收益越高
+func (cs *CustomScheduler) scoreNode(t0 *definition.Pod, c *candidate, candidates []*candidate) *scoreDetail {
+	d := &scoreDetail{node: c.node.Name}
+	if c.usageSource != usageLive {
+		d.explain("使用量来源 %s", c.usageSource)
+	}
+	if t0.PerformanceSensitive && c.cpuFactor != 1 {
+		d.explain("CPU 性能系数 %.2f，CPU 请求按 %.0fm 计", c.cpuFactor, normalizeCPU(t0, c).CPURequest)
+	}
+
+	var rates, weights []float64
+	var parts []string
+	for _, rw := range definition.BalanceResources {
+		if rw.Weight <= 0 {
+			continue
+		}
+		rate, ok := cs.utilization(t0, c, rw.Name)
+		if !ok {
+			parts = append(parts, rw.Name+" 无数据")
+			continue
+		}
+		rates = append(rates, rate)
+		weights = append(weights, rw.Weight)
+		parts = append(parts, fmt.Sprintf("%s %.2f×%g", rw.Name, rate, rw.Weight))
+	}
+	dispersion := weightedDispersion(rates, weights)
+	d.explain("使用率 %s", strings.Join(parts, " "))
+	d.explain("节点内 %s %f", definition.BalanceDispersion, dispersion)
+	alpha := definition.ClusterBalanceWeight
+	if definition.EnergyMode {
+		alpha = 0
+	}
+	if alpha > 0 {
+		across := cs.clusterDispersion(t0, c, candidates)
+		d.explain("集群 %s %f（权重 %.2f）", definition.BalanceDispersion, across, alpha)
+		dispersion = (1-alpha)*dispersion + alpha*across
+	}
+	H := definition.BalanceScoreBase - definition.BalanceScoreScale*dispersion
+	H -= cs.limitRiskPenalty(t0, c, d)
+	H -= pressurePenalty(c, d)
+	H -= healthPenalty(c, d)
+	H -= latencyPenalty(t0, c, d)
+	H -= cs.energyPenalty(t0, c, d)
+	H -= costPenalty(t0, c, d)
+	H += cs.imageLocalityBonus(t0, c, d)
+	H *= math.Pow(10, float64(len(cs.K8sNodes)-1))
+	d.score = H
+
+	if reservation := c.reservation; reservation != nil && len(reservation.Policies) > 0 {
+		d.explain("预留 CPU %.2f核 内存 %.2fGB Pod %.0f（策略 %s）",
+			reservation.CPU/1000, reservation.Memory/(1<<30), reservation.Pods, strings.Join(reservation.Policies, ","))
+	}
+	return d
+}
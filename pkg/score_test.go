@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"MBCTG/pkg/definition"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"math"
+	"testing"
+)
+
+// testCandidate 构造容量为 1000 毫核、1000 字节的候选节点，使用率即 cpu/1000、mem/1000
+func testCandidate(name string, cpu, mem float64) *candidate {
+	node := &definition.Node{
+		Name:              name,
+		CapacityCPU:       1000,
+		AllocatableCPU:    1000,
+		CapacityMemory:    1000,
+		AllocatableMemory: 1000,
+	}
+	return &candidate{
+		k8sNode:      &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}},
+		node:         node,
+		usageSource:  usageLive,
+		cpuUsed:      cpu,
+		memUsed:      mem,
+		cpuPredicted: cpu,
+		memPredicted: mem,
+		cpuFactor:    1,
+	}
+}
+
+func TestScoreNodeClusterBalanceWeight(t *testing.T) {
+	defer func(resources []definition.ResourceWeight, dispersion string, alpha float64, energy bool) {
+		definition.BalanceResources, definition.BalanceDispersion = resources, dispersion
+		definition.ClusterBalanceWeight, definition.EnergyMode = alpha, energy
+	}(definition.BalanceResources, definition.BalanceDispersion, definition.ClusterBalanceWeight, definition.EnergyMode)
+	definition.BalanceResources = []definition.ResourceWeight{
+		{Name: definition.ResourceCPU, Weight: 1},
+		{Name: definition.ResourceMemory, Weight: 1},
+	}
+	definition.EnergyMode = false
+
+	// 目标节点使用率 CPU 0.2、内存 0.6；另一节点 CPU 0.8、内存 0.8
+	target := testCandidate("a", 200, 600)
+	all := []*candidate{target, testCandidate("b", 800, 800)}
+	cs := &CustomScheduler{K8sNodes: []*corev1.Node{{}}}
+	t0 := &definition.Pod{Name: "p"}
+
+	tests := []struct {
+		name       string
+		dispersion string
+		alpha      float64
+		want       float64 // 混合后的离散度
+	}{
+		// 节点内方差 0.04；集群方差 CPU 0.09、内存 0.01，平均 0.05
+		{name: "只看节点内", dispersion: definition.DispersionVariance, alpha: 0, want: 0.04},
+		{name: "只看集群", dispersion: definition.DispersionVariance, alpha: 1, want: 0.05},
+		{name: "按权重混合", dispersion: definition.DispersionVariance, alpha: 0.3, want: 0.7*0.04 + 0.3*0.05},
+		// 节点内标准差 0.2；集群标准差 CPU 0.3、内存 0.1，平均 0.2
+		{name: "标准差度量只看集群", dispersion: definition.DispersionStdDev, alpha: 1, want: 0.2},
+		{name: "标准差度量按权重混合", dispersion: definition.DispersionStdDev, alpha: 0.5, want: 0.2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition.BalanceDispersion, definition.ClusterBalanceWeight = tt.dispersion, tt.alpha
+			got := cs.scoreNode(t0, target, all).score
+			want := definition.BalanceScoreBase - definition.BalanceScoreScale*tt.want
+			if math.Abs(got-want) > 1e-9 {
+				t.Errorf("scoreNode() score = %v, want %v", got, want)
+			}
+		})
+	}
+}
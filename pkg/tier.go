@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"MBCTG/pkg/definition"
+	"MBCTG/pkg/utils"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// podTierRequest 返回 Pod 的分层调度需求，无法获取 k8s Pod 对象时使用默认值；
+// 指定的层级不在 definition.Tiers 中时忽略该注解，按其余注解选择首选层级
+func podTierRequest(t0 *definition.Pod) definition.TierRequest {
+	k8sPod, ok := t0.K8sPod.(*corev1.Pod)
+	if !ok {
+		return definition.TierRequest{Fallback: definition.DefaultTierFallback}
+	}
+	req := utils.GetPodTierRequest(k8sPod)
+	if req.Tier != "" && !knownTier(req.Tier) {
+		fmt.Printf("Pod %s 注解 %s=%q 不是已配置的层级，忽略该注解\n", t0.Name, definition.PodTierAnnotation, req.Tier)
+		req.Tier = ""
+	}
+	return req
+}
+
+// knownTier 判断层级是否在 definition.Tiers 中
+func knownTier(name string) bool {
+	for _, tier := range definition.Tiers {
+		if tier.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// preferredTier 按注解选择首选层级：指定层级 > 计算密集选云层 > 延迟敏感或指定边缘站点选边缘层 > definition.DefaultTier
+func preferredTier(req definition.TierRequest) string {
+	switch {
+	case req.Tier != "":
+		return req.Tier
+	case req.ComputeHeavy:
+		return definition.TierCloud
+	case req.LatencySensitive || req.EdgeSite != "":
+		return definition.TierEdge
+	default:
+		return definition.DefaultTier
+	}
+}
+
+// tierOrder 返回依次尝试的层级：首选层级在前，跨层策略允许时按 definition.Tiers 的顺序追加其他层级
+func tierOrder(req definition.TierRequest) []string {
+	preferred := preferredTier(req)
+	order := []string{preferred}
+	if req.Fallback != definition.TierFallbackAny {
+		return order
+	}
+	for _, tier := range definition.Tiers {
+		if tier.Name != preferred {
+			order = append(order, tier.Name)
+		}
+	}
+	return order
+}
+
+// candidatesInTier 返回层级内的候选节点；Pod 指定边缘站点时，边缘层只保留该站点的节点
+func candidatesInTier(tier string, req definition.TierRequest, candidates []*candidate) []*candidate {
+	var result []*candidate
+	for _, c := range candidates {
+		if c.tier != tier {
+			continue
+		}
+		if tier == definition.TierEdge && req.EdgeSite != "" && c.k8sNode.Labels[definition.EdgeSiteLabel] != req.EdgeSite {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// tierHasCapacity 检查层级容量：层级内可分配量减去请求量和预留量的总和需能容纳 Pod 的请求。
+// 与 fitsAllocatable 一样按原始 CPU 请求比较，性能系数只影响实际使用量的检查
+func (cs *CustomScheduler) tierHasCapacity(t0 *definition.Pod, candidates []*candidate) (bool, string) {
+	if len(candidates) == 0 {
+		return false, "没有可用节点"
+	}
+	var freeCPU, freeMem float64
+	for _, c := range candidates {
+		requested := cs.nodeRequested(c.node.Name)
+		reservation := c.reservation
+		if reservation == nil {
+			reservation = &definition.Reservation{}
+		}
+		freeCPU += max(0, c.node.AllocatableCPU-reservation.CPU-requested.cpu)
+		freeMem += max(0, c.node.AllocatableMemory-reservation.Memory-requested.mem)
+	}
+	if t0.CPURequest > freeCPU || t0.MemoryRequest > freeMem {
+		return false, fmt.Sprintf("层级剩余 CPU %.0fm 内存 %.2fGB 不足（请求 %.0fm %.2fGB）",
+			freeCPU, freeMem/(1<<30), t0.CPURequest, t0.MemoryRequest/(1<<30))
+	}
+	return true, ""
+}
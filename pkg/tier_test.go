@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"MBCTG/pkg/definition"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+// tierPod 构造带分层注解的待调度 Pod
+func tierPod(annotations map[string]string) *definition.Pod {
+	k8sPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Annotations: annotations}}
+	return &definition.Pod{Name: "p", CPURequest: 100, K8sPod: k8sPod}
+}
+
+func TestPodTierRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        string // 首选层级
+	}{
+		{name: "指定已配置的层级", annotations: map[string]string{definition.PodTierAnnotation: definition.TierEdge}, want: definition.TierEdge},
+		{name: "未指定层级使用默认层级", want: definition.DefaultTier},
+		{name: "指定未知层级时忽略", annotations: map[string]string{definition.PodTierAnnotation: "fog"}, want: definition.DefaultTier},
+		{
+			name: "指定未知层级时按其余注解选择",
+			annotations: map[string]string{
+				definition.PodTierAnnotation:             "Edge",
+				definition.PodLatencySensitiveAnnotation: "true",
+			},
+			want: definition.TierEdge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preferredTier(podTierRequest(tierPod(tt.annotations))); got != tt.want {
+				t.Errorf("preferredTier() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecideFallbackKeepsTierWithoutCapacity(t *testing.T) {
+	defer func(tiers []definition.Tier, mode string) {
+		definition.Tiers, definition.FallbackMode = tiers, mode
+	}(definition.Tiers, definition.FallbackMode)
+	definition.Tiers = []definition.Tier{
+		{Name: definition.TierCloud, NodeNames: []string{"cloud"}},
+		{Name: definition.TierEdge, NodeNames: []string{"edge"}},
+	}
+	definition.FallbackMode = definition.FallbackLeastLoaded
+
+	// 预留量占满边缘节点，层级容量不足，但兜底策略不考虑预留
+	edge := testCandidate("edge", 100, 100)
+	edge.tier = definition.TierEdge
+	edge.reservation = &definition.Reservation{CPU: 1000, Memory: 1000}
+	cloud := testCandidate("cloud", 0, 0)
+	cloud.tier = definition.TierCloud
+	candidates := []*candidate{cloud, edge}
+
+	cs := &CustomScheduler{}
+	t0 := tierPod(map[string]string{definition.PodTierAnnotation: definition.TierEdge})
+	decision := cs.decide(t0, t0, candidates, candidates)
+	if decision.Node == nil || decision.Node.Name != "edge" {
+		t.Fatalf("decide() Node = %v, want edge (%s)", decision.Node, decision.Reason)
+	}
+	if decision.Tier != definition.TierEdge || decision.Fallback != definition.FallbackLeastLoaded {
+		t.Errorf("decide() Tier = %q Fallback = %q, want %q %q",
+			decision.Tier, decision.Fallback, definition.TierEdge, definition.FallbackLeastLoaded)
+	}
+}
@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"MBCTG/pkg/definition"
+	"MBCTG/pkg/utils"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 节点使用量数据来源
+const (
+	usageLive         = "实时"
+	usageRequestsOnly = "请求量"
+	usageLastKnown    = "最近有效值"
+)
+
+// nodeUsage 节点的 CPU、内存使用量及数据来源
+type nodeUsage struct {
+	cpu    float64 // CPU 使用量（毫核）
+	mem    float64 // 内存使用量（字节）
+	source string  // 数据来源，取值见 usage* 常量
+}
+
+// lastKnownUsage 节点最近一次有效的使用量
+type lastKnownUsage struct {
+	cpu, mem float64
+	at       time.Time
+}
+
+// collectUsage 获取候选节点的 CPU、内存使用量，并检查样本是否新鲜；
+// 缺失或过期的节点按 definition.MissingMetricsPolicy 处理，所有缺口会被打印出来
+func (cs *CustomScheduler) collectUsage(snapshot *utils.MetricsSnapshot) map[string]*nodeUsage {
+	cpuSamples, err := snapshot.NodeSamples("cpu")
+	if err != nil {
+		fmt.Println("获取节点 CPU 监控数据错误:", err)
+	}
+	memSamples, err := snapshot.NodeSamples("mem")
+	if err != nil {
+		fmt.Println("获取节点内存监控数据错误:", err)
+	}
+	lastScrape, err := snapshot.LastScrape()
+	if err != nil {
+		fmt.Println("获取节点最近采集时间错误，只检查样本时间戳:", err)
+		lastScrape = nil
+	}
+
+	now := time.Now()
+	usage := make(map[string]*nodeUsage)
+	var gaps []string
+	for _, name := range cs.K8sNodesName {
+		var problems []string
+		cpu, cpuOk := cpuSamples[name]
+		mem, memOk := memSamples[name]
+		if !cpuOk {
+			problems = append(problems, "cpu 缺失")
+		} else if age := now.Sub(cpu.Timestamp); age > definition.MetricsMaxAge {
+			problems = append(problems, fmt.Sprintf("cpu 过期 %v", age.Round(time.Second)))
+		}
+		if !memOk {
+			problems = append(problems, "mem 缺失")
+		} else if age := now.Sub(mem.Timestamp); age > definition.MetricsMaxAge {
+			problems = append(problems, fmt.Sprintf("mem 过期 %v", age.Round(time.Second)))
+		}
+		if lastScrape != nil {
+			if at, ok := lastScrape[name]; !ok {
+				problems = append(problems, "node-exporter 无采集记录")
+			} else if age := now.Sub(at); age > definition.MetricsMaxAge {
+				problems = append(problems, fmt.Sprintf("node-exporter 已 %v 未采集", age.Round(time.Second)))
+			}
+		}
+
+		if len(problems) == 0 {
+			usage[name] = &nodeUsage{cpu: cpu.Value, mem: mem.Value, source: usageLive}
+			cs.lastKnown[name] = lastKnownUsage{cpu: cpu.Value, mem: mem.Value, at: now}
+			continue
+		}
+
+		handled := cs.handleMissingUsage(name, now)
+		if handled != nil {
+			usage[name] = handled
+			gaps = append(gaps, fmt.Sprintf("%s %s，使用%s", name, strings.Join(problems, "、"), handled.source))
+		} else {
+			gaps = append(gaps, fmt.Sprintf("%s %s，不参与调度", name, strings.Join(problems, "、")))
+		}
+	}
+	if len(gaps) > 0 {
+		fmt.Printf("监控数据缺口（策略 %s）：%s\n", definition.MissingMetricsPolicy, strings.Join(gaps, "；"))
+	}
+	return usage
+}
+
+// handleMissingUsage 按 definition.MissingMetricsPolicy 为缺少有效监控数据的节点给出替代使用量，返回 nil 表示排除该节点
+func (cs *CustomScheduler) handleMissingUsage(name string, now time.Time) *nodeUsage {
+	switch definition.MissingMetricsPolicy {
+	case definition.MissingMetricsRequestsOnly:
+		requested := cs.nodeRequested(name)
+		return &nodeUsage{cpu: requested.cpu, mem: requested.mem, source: usageRequestsOnly}
+	case definition.MissingMetricsLastKnown:
+		last, ok := cs.lastKnown[name]
+		if !ok || now.Sub(last.at) > definition.LastKnownMaxAge {
+			return nil
+		}
+		return &nodeUsage{cpu: last.cpu, mem: last.mem, source: usageLastKnown}
+	default:
+		return nil
+	}
+}
+
+// forecastUsage 返回快照中节点使用量的预测值，失败时返回 nil，调用方使用当前值
+func forecastUsage(snapshot *utils.MetricsSnapshot, req string) map[string]float64 {
+	predicted, err := snapshot.NodeForecast(req)
+	if err != nil {
+		fmt.Printf("预测节点 %s 使用量错误，使用当前值: %v\n", req, err)
+		return nil
+	}
+	return predicted
+}
+
+// collectPressure 从快照获取启用的压力信号，返回 节点 -> 信号 -> 值；获取失败的信号被忽略
+func collectPressure(snapshot *utils.MetricsSnapshot) map[string]map[string]float64 {
+	pressure := make(map[string]map[string]float64)
+	for _, policy := range definition.PressurePolicies {
+		if policy.Weight <= 0 && !policy.Block {
+			continue
+		}
+		values, err := snapshot.NodeValues(policy.Signal)
+		if err != nil {
+			fmt.Printf("获取节点压力 %s 错误: %v\n", policy.Signal, err)
+			continue
+		}
+		for name, value := range values {
+			if pressure[name] == nil {
+				pressure[name] = make(map[string]float64)
+			}
+			pressure[name][policy.Signal] = value
+		}
+	}
+	return pressure
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"encoding/json"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// imagePlatforms 镜像清单缓存，镜像 -> 支持的平台（os/arch 或 arch）
+var imagePlatforms = struct {
+	mu      sync.Mutex
+	modTime time.Time
+	images  map[string][]string
+}{}
+
+// loadImagePlatforms 返回镜像清单缓存，文件修改时间变化时重新读取，读取失败时沿用已有内容
+func loadImagePlatforms() map[string][]string {
+	imagePlatforms.mu.Lock()
+	defer imagePlatforms.mu.Unlock()
+	if definition.ImagePlatformsFile == "" {
+		return nil
+	}
+	info, err := os.Stat(definition.ImagePlatformsFile)
+	if err != nil {
+		return imagePlatforms.images
+	}
+	if info.ModTime().Equal(imagePlatforms.modTime) {
+		return imagePlatforms.images
+	}
+	raw, err := os.ReadFile(definition.ImagePlatformsFile)
+	if err != nil {
+		fmt.Printf("读取镜像清单缓存错误: %v\n", err)
+		return imagePlatforms.images
+	}
+	images := make(map[string][]string)
+	if err := json.Unmarshal(raw, &images); err != nil {
+		fmt.Printf("解析镜像清单缓存错误: %v\n", err)
+		return imagePlatforms.images
+	}
+	imagePlatforms.images = images
+	imagePlatforms.modTime = info.ModTime()
+	return images
+}
+
+// imageRepository 去掉镜像的 tag 和 digest
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// imageArchitectures 返回镜像支持的架构，缓存中没有该镜像时返回 false
+func imageArchitectures(images map[string][]string, image string) ([]string, bool) {
+	platforms, ok := images[image]
+	if !ok {
+		platforms, ok = images[imageRepository(image)]
+	}
+	if !ok {
+		return nil, false
+	}
+	var archs []string
+	for _, platform := range platforms {
+		// linux/arm64/v8 -> arm64
+		parts := strings.Split(platform, "/")
+		if len(parts) > 1 {
+			parts = parts[1:]
+		}
+		archs = append(archs, parts[0])
+	}
+	return archs, true
+}
+
+// intersect 返回两个架构列表的交集，a 为 nil 表示不限制
+func intersect(a, b []string) []string {
+	if a == nil {
+		return b
+	}
+	result := []string{}
+	for _, arch := range a {
+		if Contains(b, arch) {
+			result = append(result, arch)
+		}
+	}
+	return result
+}
+
+// PodArchitectures 返回 Pod 可以运行的 CPU 架构：注解优先，其次为 nodeSelector 中的架构，再与镜像清单缓存中
+// 所有容器镜像支持的架构取交集；返回 nil 表示不限制
+func PodArchitectures(pod *corev1.Pod) []string {
+	if value, ok := pod.ObjectMeta.Annotations[definition.PodArchitecturesAnnotation]; ok {
+		var archs []string
+		for _, arch := range strings.Split(value, ",") {
+			if arch = strings.TrimSpace(arch); arch != "" {
+				archs = append(archs, arch)
+			}
+		}
+		return archs
+	}
+	var archs []string
+	if arch, ok := pod.Spec.NodeSelector[definition.NodeArchLabel]; ok {
+		archs = []string{arch}
+	}
+	images := loadImagePlatforms()
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		if supported, ok := imageArchitectures(images, container.Image); ok {
+			archs = intersect(archs, supported)
+		}
+	}
+	return archs
+}
+
+// NodeArchitecture 返回节点的 CPU 架构，优先使用 kubernetes.io/arch 标签
+func NodeArchitecture(n *corev1.Node) string {
+	if arch, ok := n.Labels[definition.NodeArchLabel]; ok {
+		return arch
+	}
+	return n.Status.NodeInfo.Architecture
+}
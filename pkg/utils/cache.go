@@ -0,0 +1,301 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MetricsSnapshot 某一时刻从数据源获取的节点监控数据，一轮调度中的所有使用方共享同一份快照
+type MetricsSnapshot struct {
+	Time time.Time
+	// Stale 为 true 时表示快照已超过 definition.MetricsCacheTTL，新快照正在刷新中
+	Stale        bool
+	nodes        map[string]map[string]NodeSample // 监控项 -> 节点 -> 样本
+	errs         map[string]error                 // 获取失败的监控项
+	lastScrape   map[string]time.Time
+	scrapeErr    error
+	forecasts    map[string]map[string]float64 // 监控项 -> 节点 -> 预测值
+	forecastErrs map[string]error              // 预测失败的监控项
+}
+
+// NodeSamples 返回快照中的监控项；快照未包含该监控项时直接从数据源获取
+func (s *MetricsSnapshot) NodeSamples(req string) (map[string]NodeSample, error) {
+	if err, ok := s.errs[req]; ok {
+		return nil, err
+	}
+	if samples, ok := s.nodes[req]; ok {
+		return samples, nil
+	}
+	return Metrics.NodeUsage(req)
+}
+
+// NodeValues 返回快照中监控项的值
+func (s *MetricsSnapshot) NodeValues(req string) (map[string]float64, error) {
+	samples, err := s.NodeSamples(req)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64)
+	for name, sample := range samples {
+		values[name] = sample.Value
+	}
+	return values, nil
+}
+
+// NodeForecast 返回快照中监控项的预测值；快照未包含该监控项时以快照中的当前值为基准重新预测
+func (s *MetricsSnapshot) NodeForecast(req string) (map[string]float64, error) {
+	if err, ok := s.forecastErrs[req]; ok {
+		return nil, err
+	}
+	if predicted, ok := s.forecasts[req]; ok {
+		return predicted, nil
+	}
+	current, err := s.NodeValues(req)
+	if err != nil {
+		return nil, err
+	}
+	return GetNodeForecast(req, current)
+}
+
+// LastScrape 返回快照中每个节点最近一次采集的时间
+func (s *MetricsSnapshot) LastScrape() (map[string]time.Time, error) {
+	return s.lastScrape, s.scrapeErr
+}
+
+// snapshotRequests 快照包含的监控项：cpu、mem、均衡打分启用的其他资源维度以及压力、健康信号
+func snapshotRequests() []string {
+	reqs := []string{"cpu", "mem"}
+	for _, policy := range definition.HealthPolicies {
+		if policy.Signal == definition.HealthOOMKills || policy.Signal == definition.HealthReboots {
+			reqs = append(reqs, policy.Signal)
+		}
+	}
+	for _, policy := range definition.PressurePolicies {
+		if policy.Weight > 0 || policy.Block {
+			reqs = append(reqs, policy.Signal)
+		}
+	}
+	for _, rw := range definition.BalanceResources {
+		if rw.Weight <= 0 {
+			continue
+		}
+		switch rw.Name {
+		case definition.ResourceStorage:
+			reqs = append(reqs, "storage")
+		case definition.ResourceNetwork:
+			reqs = append(reqs, "net", "net-speed")
+		case definition.ResourceDiskIO:
+			reqs = append(reqs, "disk-io", "disk-iops")
+		}
+	}
+	return reqs
+}
+
+// forecastRequests 快照中需要预测的监控项，预测的区间查询与快照一同按 TTL 缓存
+var forecastRequests = []string{"cpu", "mem"}
+
+// errSnapshotTimeout 监控项未能在 definition.MetricsSnapshotTimeout 内返回
+var errSnapshotTimeout = errors.New("获取监控快照超时")
+
+// pendingQuery 快照中一项正在进行的查询，完成时关闭 done
+type pendingQuery struct {
+	done chan struct{}
+	err  error
+}
+
+// start 在新的 goroutine 中执行 fn 并记录其错误
+func (q *pendingQuery) start(fn func() error) {
+	q.done = make(chan struct{})
+	go func() {
+		defer close(q.done)
+		q.err = fn()
+	}()
+}
+
+// wait 等待查询完成，超过 deadline 时返回 errSnapshotTimeout；超时的查询在后台继续执行，其结果被丢弃
+func (q *pendingQuery) wait(deadline <-chan struct{}) error {
+	select {
+	case <-q.done:
+		return q.err
+	case <-deadline:
+		return errSnapshotTimeout
+	}
+}
+
+// fetchSnapshot 从当前数据源并发获取一份完整快照，整份快照共用 definition.MetricsSnapshotTimeout 的期限，
+// 超时未返回的监控项按获取失败处理
+func fetchSnapshot() *MetricsSnapshot {
+	s := &MetricsSnapshot{
+		Time:         time.Now(),
+		nodes:        make(map[string]map[string]NodeSample),
+		errs:         make(map[string]error),
+		forecasts:    make(map[string]map[string]float64),
+		forecastErrs: make(map[string]error),
+	}
+	// 到期时关闭 deadline，所有等待方都能感知超时
+	deadline := make(chan struct{})
+	timer := time.AfterFunc(definition.MetricsSnapshotTimeout, func() { close(deadline) })
+	defer timer.Stop()
+
+	reqs := snapshotRequests()
+	samples := make(map[string]*map[string]NodeSample, len(reqs))
+	usage := make(map[string]*pendingQuery, len(reqs))
+	for _, req := range reqs {
+		if _, ok := usage[req]; ok {
+			continue
+		}
+		result := new(map[string]NodeSample)
+		q := &pendingQuery{}
+		q.start(func() (err error) {
+			*result, err = Metrics.NodeUsage(req)
+			return err
+		})
+		samples[req], usage[req] = result, q
+	}
+
+	var lastScrape map[string]time.Time
+	scrape := &pendingQuery{}
+	scrape.start(func() (err error) {
+		lastScrape, err = Metrics.NodeLastScrape()
+		return err
+	})
+
+	// 预测以同一份快照中的当前值为基准，当前值返回后再发起区间查询
+	predictions := make(map[string]*map[string]float64, len(forecastRequests))
+	forecasts := make(map[string]*pendingQuery, len(forecastRequests))
+	for _, req := range forecastRequests {
+		result := new(map[string]float64)
+		q := &pendingQuery{}
+		current, ok := usage[req]
+		q.start(func() error {
+			var nodeSamples map[string]NodeSample
+			if ok {
+				<-current.done
+				if current.err != nil {
+					return current.err
+				}
+				nodeSamples = *samples[req]
+			} else {
+				var err error
+				if nodeSamples, err = Metrics.NodeUsage(req); err != nil {
+					return err
+				}
+			}
+			values := make(map[string]float64, len(nodeSamples))
+			for name, sample := range nodeSamples {
+				values[name] = sample.Value
+			}
+			predicted, err := GetNodeForecast(req, values)
+			*result = predicted
+			return err
+		})
+		predictions[req], forecasts[req] = result, q
+	}
+
+	for req, q := range usage {
+		if err := q.wait(deadline); err != nil {
+			s.errs[req] = err
+			continue
+		}
+		s.nodes[req] = *samples[req]
+	}
+	if s.scrapeErr = scrape.wait(deadline); s.scrapeErr == nil {
+		s.lastScrape = lastScrape
+	}
+	for req, q := range forecasts {
+		if err := q.wait(deadline); err != nil {
+			s.forecastErrs[req] = err
+			continue
+		}
+		s.forecasts[req] = *predictions[req]
+	}
+	return s
+}
+
+// MetricsCache 带 TTL 的监控快照缓存，并发的刷新请求只会触发一次查询
+type MetricsCache struct {
+	mu       sync.Mutex
+	snapshot *MetricsSnapshot
+	inflight chan struct{} // 正在进行的刷新，完成时关闭
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// Cache 全局监控快照缓存
+var Cache = &MetricsCache{}
+
+// Snapshot 返回未超过 definition.MetricsCacheTTL 的快照，否则刷新后返回；
+// 已有刷新在进行时不等待，直接返回标记为 Stale 的旧快照
+func (c *MetricsCache) Snapshot() *MetricsSnapshot {
+	c.mu.Lock()
+	if c.snapshot != nil && time.Since(c.snapshot.Time) < definition.MetricsCacheTTL {
+		s := c.snapshot
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return s
+	}
+	c.misses.Add(1)
+	if c.snapshot != nil && c.inflight != nil {
+		stale := *c.snapshot
+		c.mu.Unlock()
+		stale.Stale = true
+		return &stale
+	}
+	return c.refreshLocked()
+}
+
+// Refresh 强制刷新快照
+func (c *MetricsCache) Refresh() *MetricsSnapshot {
+	c.mu.Lock()
+	return c.refreshLocked()
+}
+
+// refreshLocked 刷新快照，调用方需持有 mu，返回前释放；已有刷新在进行时等待其结果
+func (c *MetricsCache) refreshLocked() *MetricsSnapshot {
+	if ch := c.inflight; ch != nil {
+		c.mu.Unlock()
+		<-ch
+		c.mu.Lock()
+		s := c.snapshot
+		c.mu.Unlock()
+		return s
+	}
+	ch := make(chan struct{})
+	c.inflight = ch
+	c.mu.Unlock()
+
+	s := fetchSnapshot()
+
+	c.mu.Lock()
+	c.snapshot = s
+	c.inflight = nil
+	c.mu.Unlock()
+	close(ch)
+	return s
+}
+
+// Stats 返回缓存命中与未命中次数
+func (c *MetricsCache) Stats() (hits, misses uint64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// RunRefresher 每隔 interval 刷新一次快照，使缓存保持可用
+func (c *MetricsCache) RunRefresher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s := c.Refresh()
+		for req, err := range s.errs {
+			fmt.Printf("刷新监控快照 %s 错误: %v\n", req, err)
+		}
+		for req, err := range s.forecastErrs {
+			fmt.Printf("刷新监控快照预测 %s 错误: %v\n", req, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSource 统计 NodeUsage 调用次数的数据源，release 非 nil 时查询阻塞到 release 关闭
+type fakeSource struct {
+	calls    atomic.Int64
+	scrapes  atomic.Int64
+	release  chan struct{}
+	inflight sync.WaitGroup // 尚未返回的 NodeUsage、NodeLastScrape 调用
+}
+
+func (s *fakeSource) Name() string { return "fake" }
+
+func (s *fakeSource) NodeUsage(req string) (map[string]NodeSample, error) {
+	s.inflight.Add(1)
+	defer s.inflight.Done()
+	s.calls.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	return map[string]NodeSample{"node1": {Value: 1, Timestamp: time.Now()}}, nil
+}
+
+func (s *fakeSource) NodeUsageRange(req string, start, end time.Time, step time.Duration) (map[string][]float64, error) {
+	return nil, nil
+}
+
+func (s *fakeSource) NodeLastScrape() (map[string]time.Time, error) {
+	s.inflight.Add(1)
+	defer s.inflight.Done()
+	s.scrapes.Add(1)
+	return nil, nil
+}
+
+func (s *fakeSource) PodUsage(req, namespace, podName string) (float64, error) { return 0, nil }
+
+func (s *fakeSource) PodUsageRange(req, namespace, podName string, start, end time.Time, step time.Duration) ([]float64, error) {
+	return nil, nil
+}
+
+func (s *fakeSource) AllPodsUsage() (map[string]*PodResourceUsage, error) { return nil, nil }
+
+func (s *fakeSource) AllPodsUsageRange(start, end time.Time, step time.Duration) (map[string]*PodUsageSeries, error) {
+	return nil, nil
+}
+
+// useFakeSource 替换数据源并关闭预测，只保留 cpu、mem 两个监控项，测试结束后恢复
+func useFakeSource(t *testing.T, source *fakeSource) {
+	metrics, method, timeout := Metrics, definition.ForecastMethod, definition.MetricsSnapshotTimeout
+	health, pressure, balance := definition.HealthPolicies, definition.PressurePolicies, definition.BalanceResources
+	t.Cleanup(func() {
+		Metrics, definition.ForecastMethod, definition.MetricsSnapshotTimeout = metrics, method, timeout
+		definition.HealthPolicies, definition.PressurePolicies, definition.BalanceResources = health, pressure, balance
+	})
+	Metrics = source
+	definition.ForecastMethod = definition.ForecastInstant
+	definition.HealthPolicies, definition.PressurePolicies, definition.BalanceResources = nil, nil, nil
+}
+
+func TestMetricsCacheSingleFlight(t *testing.T) {
+	source := &fakeSource{release: make(chan struct{})}
+	useFakeSource(t, source)
+	cache := &MetricsCache{}
+
+	const callers = 10
+	snapshots := make([]*MetricsSnapshot, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			snapshots[i] = cache.Snapshot()
+		}(i)
+	}
+	// 等所有调用方都进入刷新后再让查询返回
+	time.Sleep(50 * time.Millisecond)
+	close(source.release)
+	wg.Wait()
+
+	if got := source.calls.Load(); got != 2 {
+		t.Errorf("NodeUsage calls = %d, want 2 (cpu and mem once)", got)
+	}
+	for i, s := range snapshots {
+		if s != snapshots[0] {
+			t.Fatalf("caller %d got a different snapshot", i)
+		}
+	}
+	if s := cache.Snapshot(); s != snapshots[0] || s.Stale {
+		t.Errorf("Snapshot() within TTL should return the cached snapshot")
+	}
+	if hits, misses := cache.Stats(); hits+misses != callers+1 || misses == 0 {
+		t.Errorf("Stats() = (%d, %d), want %d lookups with at least one miss", hits, misses, callers+1)
+	}
+}
+
+func TestMetricsCacheStaleWhileRefreshing(t *testing.T) {
+	source := &fakeSource{release: make(chan struct{})}
+	useFakeSource(t, source)
+	old := &MetricsSnapshot{Time: time.Now().Add(-time.Hour)}
+	cache := &MetricsCache{snapshot: old}
+
+	done := make(chan *MetricsSnapshot)
+	go func() { done <- cache.Refresh() }()
+	for source.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	s := cache.Snapshot()
+	if !s.Stale || s.Time != old.Time {
+		t.Errorf("Snapshot() during refresh = %+v, want stale copy of the previous snapshot", s)
+	}
+	if old.Stale {
+		t.Error("Snapshot() must not mark the cached snapshot itself as stale")
+	}
+
+	close(source.release)
+	fresh := <-done
+	if fresh.Stale || cache.Snapshot() != fresh {
+		t.Error("Snapshot() after refresh should return the fresh snapshot")
+	}
+}
+
+func TestFetchSnapshotTimeout(t *testing.T) {
+	source := &fakeSource{release: make(chan struct{})}
+	useFakeSource(t, source)
+	definition.MetricsSnapshotTimeout = 50 * time.Millisecond
+	// 超时的查询在后台继续执行，不预测以便测试结束前等待它们返回
+	defer func(reqs []string) { forecastRequests = reqs }(forecastRequests)
+	forecastRequests = nil
+	defer func() {
+		// 等所有查询都已发起，再放行并等待它们返回
+		for source.calls.Load() < 2 || source.scrapes.Load() < 1 {
+			time.Sleep(time.Millisecond)
+		}
+		close(source.release)
+		source.inflight.Wait()
+	}()
+
+	begin := time.Now()
+	s := fetchSnapshot()
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("fetchSnapshot() took %v, want bounded by MetricsSnapshotTimeout", elapsed)
+	}
+	for _, req := range []string{"cpu", "mem"} {
+		if _, err := s.NodeSamples(req); !errors.Is(err, errSnapshotTimeout) {
+			t.Errorf("NodeSamples(%q) error = %v, want %v", req, err, errSnapshotTimeout)
+		}
+	}
+}
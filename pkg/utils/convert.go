@@ -4,50 +4,63 @@ import (
 	"MBCTG/pkg/definition"
 	"errors"
 	corev1 "k8s.io/api/core/v1"
-	"strconv"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"strings"
 )
 
+// parseQuantity 将资源字符串解析为 resource.Quantity，支持 Kubernetes 的全部数量格式
+// 例如： "1024"、"2Gi"、"1.5G"、"1e9"、"250m"
+func parseQuantity(resourceString string) (resource.Quantity, error) {
+	s := strings.TrimSpace(resourceString)
+	if s == "" {
+		return resource.Quantity{}, errors.New("资源字符串为空")
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return resource.Quantity{}, errors.New("不支持的资源格式: " + resourceString)
+	}
+	if q.Sign() < 0 {
+		return resource.Quantity{}, errors.New("资源数量不能为负数: " + resourceString)
+	}
+	return q, nil
+}
+
 // memConvertToInt 将内存资源字符串（例如 "2Gi"）转换为字节数2147483648
 func memConvertToInt(resourceString string) (float64, error) {
-	if strings.Contains(resourceString, "Ki") {
-		valStr := strings.TrimSuffix(resourceString, "Ki") //如果 `s` 以 `suffix` 结尾，则去除该后缀。
-		val, err := strconv.ParseInt(valStr, 10, 64)       // 转十进制int
-		if err != nil {
-			return 0, err
-		}
-		return float64(val * 1 << 10), nil
-	} else if strings.Contains(resourceString, "Mi") {
-		valStr := strings.TrimSuffix(resourceString, "Mi")
-		val, err := strconv.ParseInt(valStr, 10, 64)
-		if err != nil {
-			return 0, err
-		}
-		return float64(val * 1 << 20), nil
-	} else if strings.Contains(resourceString, "Gi") {
-		valStr := strings.TrimSuffix(resourceString, "Gi")
-		val, err := strconv.ParseInt(valStr, 10, 64)
-		if err != nil {
-			return 0, err
-		}
-		return float64(val * 1 << 30), nil
+	q, err := parseQuantity(resourceString)
+	if err != nil {
+		return 0, err
 	}
-	return 0, errors.New("不支持的内存格式: " + resourceString)
+	return quantityToBytes(q), nil
 }
 
 // cpuConvertToMilliValue 将 CPU 资源字符串转换为毫核数
 // 例如： "2" --> 2000， "250m" --> 250
 func cpuConvertToMilliValue(resourceString string) (float64, error) {
-	if strings.Contains(resourceString, "m") {
-		valStr := strings.TrimSuffix(resourceString, "m")
-		return strconv.ParseFloat(valStr, 64)
-	} else {
-		val, err := strconv.ParseFloat(resourceString, 64)
-		if err != nil {
-			return 0, err
-		}
-		return val * 1000, nil
+	q, err := parseQuantity(resourceString)
+	if err != nil {
+		return 0, err
+	}
+	return quantityToMilli(q), nil
+}
+
+// countConvertToInt 将数量字符串（例如 Pod 数 "110"）转换为整数值
+func countConvertToInt(resourceString string) (float64, error) {
+	q, err := parseQuantity(resourceString)
+	if err != nil {
+		return 0, err
 	}
+	return quantityToBytes(q), nil
+}
+
+// quantityToBytes 将 Quantity 转换为整数值（内存、存储为字节数，Pod 数为个数），不足 1 的部分向上取整
+func quantityToBytes(q resource.Quantity) float64 {
+	return float64(q.Value())
+}
+
+// quantityToMilli 将 Quantity 转换为千分之一单位（CPU 为毫核数），不足 1m 的部分向上取整
+func quantityToMilli(q resource.Quantity) float64 {
+	return float64(q.MilliValue())
 }
 
 // ConvertK8sPodToMyPod 将 Kubernetes Pod 对象转换为自定义 Pod 对象
@@ -56,60 +69,61 @@ func ConvertK8sPodToMyPod(k8sPod *corev1.Pod) *definition.Pod {
 	cpuReq := GetK8sPodCpuRequest(k8sPod)
 	memLimits := GetK8sPodMemoryLimits(k8sPod)
 	cpuLimits := GetK8sPodCpuLimits(k8sPod)
+	storageReq := GetK8sPodStorageRequest(k8sPod)
+	netBandwidth := GetK8sPodAnnotationQuantity(k8sPod, definition.PodNetworkBandwidthAnnotation)
+	diskIOPS := GetK8sPodAnnotationQuantity(k8sPod, definition.PodDiskIOPSAnnotation)
+	sensitive := IsPodPerformanceSensitive(k8sPod)
+	archs := PodArchitectures(k8sPod)
+
+	pod := definition.NewPod(k8sPod.ObjectMeta.Name, k8sPod.ObjectMeta.Namespace, k8sPod.Spec.NodeName, k8sPod,
+		memReq, cpuReq, memLimits, cpuLimits, storageReq, netBandwidth, diskIOPS, sensitive, archs)
+	pod.LatencyTarget, pod.MaxRTT = GetPodLatencyRequest(k8sPod)
+	pod.CostClass, pod.InterruptionTolerant = GetPodCostRequest(k8sPod)
+	return pod
+}
+
+// ConvertK8sNodeToMyNode 将单个 k8s 的 node 对象转换为我的 Node 对象；节点没有地址时返回 nil
+func ConvertK8sNodeToMyNode(n *corev1.Node) *definition.Node {
+	if len(n.Status.Addresses) == 0 {
+		return nil
+	}
+	// 直接使用 Quantity 计算，缺失的资源按 0 处理
+	cpuCap := n.Status.Capacity[corev1.ResourceCPU]
+	cpuAlloc := n.Status.Allocatable[corev1.ResourceCPU]
+	memCap := n.Status.Capacity[corev1.ResourceMemory]
+	memAlloc := n.Status.Allocatable[corev1.ResourceMemory]
+	storageCap := n.Status.Capacity[corev1.ResourceEphemeralStorage]
+	storageAlloc := n.Status.Allocatable[corev1.ResourceEphemeralStorage]
+	podsCap := n.Status.Capacity[corev1.ResourcePods]
+	podsAlloc := n.Status.Allocatable[corev1.ResourcePods]
 
-	return definition.NewPod(k8sPod.ObjectMeta.Name, k8sPod.Spec.NodeName, k8sPod, memReq, cpuReq, memLimits, cpuLimits)
+	return definition.NewNode(
+		n.Status.Addresses[0].Address,
+		n.ObjectMeta.Name,
+		n,
+		quantityToMilli(cpuCap),
+		quantityToMilli(cpuAlloc),
+		quantityToBytes(memCap),
+		quantityToBytes(memAlloc),
+		quantityToBytes(storageCap),
+		quantityToBytes(storageAlloc),
+		quantityToBytes(podsCap),
+		quantityToBytes(podsAlloc),
+	)
 }
 
-// ConvertAllK8sNodesToMyNodes 所有k8s的node对象转换为我的Node对象
+// ConvertAllK8sNodesToMyNodes 所有层级的k8s node对象转换为我的Node对象
 func ConvertAllK8sNodesToMyNodes() (map[string]*definition.Node, error) {
-	nodes, err := K8sNodesAvailable(true)
+	nodes, err := K8sTierNodesAvailable()
 	if err != nil {
 		return nil, err
 	}
 
 	myNodes := make(map[string]*definition.Node)
 	for _, n := range nodes {
-		if len(n.Status.Addresses) == 0 {
-			continue
+		if myNode := ConvertK8sNodeToMyNode(n); myNode != nil {
+			myNodes[n.ObjectMeta.Name] = myNode
 		}
-		// 先将 map 中的 Quantity 复制到局部变量中
-		cpuCapQuantity := n.Status.Capacity[corev1.ResourceCPU]
-		cpuAllocQuantity := n.Status.Allocatable[corev1.ResourceCPU]
-		memCapQuantity := n.Status.Capacity[corev1.ResourceMemory]
-		memAllocQuantity := n.Status.Allocatable[corev1.ResourceMemory]
-
-		// 再对局部变量取地址调用 String() 方法
-		cpuCapacityStr := (&cpuCapQuantity).String()
-		cpuAllocStr := (&cpuAllocQuantity).String()
-		memCapacityStr := (&memCapQuantity).String()
-		memAllocStr := (&memAllocQuantity).String()
-
-		cpuCapacity, err := cpuConvertToMilliValue(cpuCapacityStr)
-		if err != nil {
-			return nil, err
-		}
-		cpuAlloc, err := cpuConvertToMilliValue(cpuAllocStr)
-		if err != nil {
-			return nil, err
-		}
-		memCapacity, err := memConvertToInt(memCapacityStr)
-		if err != nil {
-			return nil, err
-		}
-		memAlloc, err := memConvertToInt(memAllocStr)
-		if err != nil {
-			return nil, err
-		}
-
-		myNodes[n.ObjectMeta.Name] = definition.NewNode(
-			n.Status.Addresses[0].Address,
-			n.ObjectMeta.Name,
-			n,
-			cpuCapacity,
-			cpuAlloc,
-			memCapacity,
-			memAlloc,
-		)
 	}
 	return myNodes, nil
 }
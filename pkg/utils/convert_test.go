@@ -0,0 +1,190 @@
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestMemConvertToInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{name: "纯字节", input: "1024", want: 1024},
+		{name: "零值", input: "0", want: 0},
+		{name: "Ki", input: "3Ki", want: 3 << 10},
+		{name: "Mi", input: "502Mi", want: 502 << 20},
+		{name: "Gi", input: "2Gi", want: 2 << 30},
+		{name: "Ti", input: "1Ti", want: 1 << 40},
+		{name: "Pi", input: "1Pi", want: 1 << 50},
+		{name: "Ei", input: "1Ei", want: 1 << 60},
+		{name: "小数二进制后缀", input: "1.5Gi", want: 1.5 * (1 << 30)},
+		{name: "十进制k", input: "4k", want: 4e3},
+		{name: "十进制M", input: "128M", want: 128e6},
+		{name: "十进制G", input: "16G", want: 16e9},
+		{name: "十进制T", input: "2T", want: 2e12},
+		{name: "十进制P", input: "1P", want: 1e15},
+		{name: "十进制E", input: "1E", want: 1e18},
+		{name: "小写指数", input: "1e9", want: 1e9},
+		{name: "大写指数", input: "12E6", want: 12e6},
+		{name: "小数字节向上取整", input: "100m", want: 1},
+		{name: "首尾空白", input: " 8Gi ", want: 8 << 30},
+		{name: "空字符串", input: "", wantErr: true},
+		{name: "非法后缀", input: "1Xi", wantErr: true},
+		{name: "非数字", input: "abc", wantErr: true},
+		{name: "负数", input: "-1Gi", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := memConvertToInt(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("memConvertToInt(%q) err = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("memConvertToInt(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCpuConvertToMilliValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{name: "整数核", input: "2", want: 2000},
+		{name: "小数核", input: "0.8", want: 800},
+		{name: "毫核", input: "250m", want: 250},
+		{name: "微核向上取整", input: "100u", want: 1},
+		{name: "纳核向上取整", input: "1500000n", want: 2},
+		{name: "十进制k", input: "1k", want: 1e6},
+		{name: "指数", input: "1e1", want: 10000},
+		{name: "二进制后缀", input: "1Ki", want: 1024000},
+		{name: "零值", input: "0", want: 0},
+		{name: "空字符串", input: "", wantErr: true},
+		{name: "非法后缀", input: "2cores", wantErr: true},
+		{name: "负数", input: "-500m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cpuConvertToMilliValue(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cpuConvertToMilliValue(%q) err = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("cpuConvertToMilliValue(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertK8sNodeToMyNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		capacity    corev1.ResourceList
+		allocatable corev1.ResourceList
+		noAddress   bool
+		wantCPU     [2]float64
+		wantMem     [2]float64
+		wantStorage [2]float64
+		wantPods    [2]float64
+		wantNil     bool
+	}{
+		{
+			name: "Ki内存与整数核",
+			capacity: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("8"),
+				corev1.ResourceMemory:           resource.MustParse("32765604Ki"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("102687672Ki"),
+				corev1.ResourcePods:             resource.MustParse("110"),
+			},
+			allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("7800m"),
+				corev1.ResourceMemory:           resource.MustParse("32663204Ki"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("94636958854"),
+				corev1.ResourcePods:             resource.MustParse("110"),
+			},
+			wantCPU:     [2]float64{8000, 7800},
+			wantMem:     [2]float64{32765604 << 10, 32663204 << 10},
+			wantStorage: [2]float64{102687672 << 10, 94636958854},
+			wantPods:    [2]float64{110, 110},
+		},
+		{
+			name: "十进制与指数格式",
+			capacity: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("16G"),
+			},
+			allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("3.5"),
+				corev1.ResourceMemory: resource.MustParse("15e9"),
+			},
+			wantCPU: [2]float64{4000, 3500},
+			wantMem: [2]float64{16e9, 15e9},
+		},
+		{
+			name: "Ti内存",
+			capacity: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("128"),
+				corev1.ResourceMemory: resource.MustParse("2Ti"),
+			},
+			allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("127"),
+				corev1.ResourceMemory: resource.MustParse("2047Gi"),
+			},
+			wantCPU: [2]float64{128000, 127000},
+			wantMem: [2]float64{2 << 40, 2047 << 30},
+		},
+		{
+			name:      "无地址节点",
+			capacity:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			noAddress: true,
+			wantNil:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+				Status: corev1.NodeStatus{
+					Capacity:    tt.capacity,
+					Allocatable: tt.allocatable,
+				},
+			}
+			if !tt.noAddress {
+				n.Status.Addresses = []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "192.168.3.222"}}
+			}
+			got := ConvertK8sNodeToMyNode(n)
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("ConvertK8sNodeToMyNode() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("ConvertK8sNodeToMyNode() = nil")
+			}
+			if got.CapacityCPU != tt.wantCPU[0] || got.AllocatableCPU != tt.wantCPU[1] {
+				t.Errorf("CPU = (%v, %v), want %v", got.CapacityCPU, got.AllocatableCPU, tt.wantCPU)
+			}
+			if got.CapacityMemory != tt.wantMem[0] || got.AllocatableMemory != tt.wantMem[1] {
+				t.Errorf("Memory = (%v, %v), want %v", got.CapacityMemory, got.AllocatableMemory, tt.wantMem)
+			}
+			if got.CapacityStorage != tt.wantStorage[0] || got.AllocatableStorage != tt.wantStorage[1] {
+				t.Errorf("Storage = (%v, %v), want %v", got.CapacityStorage, got.AllocatableStorage, tt.wantStorage)
+			}
+			if got.CapacityPods != tt.wantPods[0] || got.AllocatablePods != tt.wantPods[1] {
+				t.Errorf("Pods = (%v, %v), want %v", got.CapacityPods, got.AllocatablePods, tt.wantPods)
+			}
+			if got.IP != "192.168.3.222" || got.Name != "node1" {
+				t.Errorf("IP/Name = (%s, %s)", got.IP, got.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"strconv"
+)
+
+// ResolveNodeCost 返回节点的小时成本和是否为 spot 实例：成本以节点注解优先，其次是第一条命中的策略；
+// 带有 definition.NodeSpotLabel 的节点始终视为 spot
+func ResolveNodeCost(policies []definition.NodeCost, n *corev1.Node) (float64, bool) {
+	var cost float64
+	var spot bool
+	for _, policy := range policies {
+		if nodeMatches(policy.NodeSelector, n) {
+			cost, spot = policy.HourlyCost, policy.Spot
+			break
+		}
+	}
+	if value, ok := n.Annotations[definition.NodeHourlyCostAnnotation]; ok {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed >= 0 {
+			cost = parsed
+		} else {
+			fmt.Printf("节点 %s 注解 %s=%q 无效，使用配置的成本\n", n.Name, definition.NodeHourlyCostAnnotation, value)
+		}
+	}
+	if n.Labels[definition.NodeSpotLabel] == "true" {
+		spot = true
+	}
+	return cost, spot
+}
+
+// GetPodCostRequest 解析 Pod 的成本敏感等级和是否容忍中断
+func GetPodCostRequest(pod *corev1.Pod) (string, bool) {
+	class, ok := pod.ObjectMeta.Annotations[definition.PodCostClassAnnotation]
+	if _, known := definition.CostClassWeights[class]; !ok || !known {
+		class = definition.CostClassStandard
+	}
+	tolerant, _ := strconv.ParseBool(pod.ObjectMeta.Annotations[definition.PodInterruptionTolerantAnnotation])
+	return class, tolerant
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Forecaster 根据按时间升序排列、间隔固定的历史样本（最后一个样本为当前时刻），预测之后第 1..horizon 个步长的值
+type Forecaster interface {
+	Forecast(series []float64, horizon int) []float64
+}
+
+// AggregateHorizon 按 definition.ForecastAggregate 将预测区间汇总为一个值：区间内的最大值或百分位数，
+// 使即将进入周期性峰值的节点在打分时不再显得空闲
+func AggregateHorizon(path []float64) float64 {
+	if len(path) == 0 {
+		return 0
+	}
+	if definition.ForecastAggregate == definition.ForecastAggregatePercentile {
+		return percentile(path, definition.ForecastAggregatePercentileValue)
+	}
+	peak := path[0]
+	for _, x := range path[1:] {
+		peak = math.Max(peak, x)
+	}
+	return peak
+}
+
+// flat 返回长度为 horizon、值均为 v 的预测区间
+func flat(v float64, horizon int) []float64 {
+	path := make([]float64, horizon)
+	for i := range path {
+		path[i] = v
+	}
+	return path
+}
+
+// NewForecaster 根据预测方法名称创建 Forecaster，参数取自 definition 中的配置
+func NewForecaster(method string) (Forecaster, error) {
+	switch method {
+	case definition.ForecastEWMA:
+		return &EWMAForecaster{Alpha: definition.EWMAAlpha}, nil
+	case definition.ForecastHoltWinters:
+		season := int(definition.HoltWintersSeason / definition.ForecastStep)
+		return &HoltWintersForecaster{
+			Alpha:  definition.HoltWintersAlpha,
+			Beta:   definition.HoltWintersBeta,
+			Gamma:  definition.HoltWintersGamma,
+			Season: season,
+		}, nil
+	case definition.ForecastPercentile:
+		return &PercentileForecaster{Percentile: definition.ForecastPercentileValue}, nil
+	default:
+		return nil, fmt.Errorf("不支持的预测方法: %s", method)
+	}
+}
+
+// EWMAForecaster 指数加权移动平均，区间内每一步的预测值均为最后的平滑值
+type EWMAForecaster struct {
+	Alpha float64 // 平滑系数（0~1），越大越偏重近期样本
+}
+
+func (f *EWMAForecaster) Forecast(series []float64, horizon int) []float64 {
+	if len(series) == 0 {
+		return flat(0, horizon)
+	}
+	s := series[0]
+	for _, x := range series[1:] {
+		s = f.Alpha*x + (1-f.Alpha)*s
+	}
+	return flat(s, horizon)
+}
+
+// HoltWintersForecaster 加法 Holt-Winters（三次指数平滑），样本不足两个周期时退化为 Holt 线性趋势法
+type HoltWintersForecaster struct {
+	Alpha  float64 // 水平平滑系数
+	Beta   float64 // 趋势平滑系数
+	Gamma  float64 // 季节平滑系数
+	Season int     // 周期包含的样本数
+}
+
+func (f *HoltWintersForecaster) Forecast(series []float64, horizon int) []float64 {
+	n := len(series)
+	if n == 0 {
+		return flat(0, horizon)
+	}
+	if n == 1 {
+		return flat(series[0], horizon)
+	}
+	path := make([]float64, horizon)
+	L := f.Season
+	if L < 2 || n < 2*L {
+		// Holt 线性趋势法
+		level, trend := series[0], series[1]-series[0]
+		for _, x := range series[1:] {
+			prevLevel := level
+			level = f.Alpha*x + (1-f.Alpha)*(level+trend)
+			trend = f.Beta*(level-prevLevel) + (1-f.Beta)*trend
+		}
+		for h := 1; h <= horizon; h++ {
+			path[h-1] = level + float64(h)*trend
+		}
+		return path
+	}
+
+	// 用前两个周期初始化水平、趋势和季节分量
+	first, second := mean(series[:L]), mean(series[L:2*L])
+	level := first
+	trend := (second - first) / float64(L)
+	seasonal := make([]float64, L)
+	for i := 0; i < L; i++ {
+		seasonal[i] = series[i] - first
+	}
+	for t := L; t < n; t++ {
+		x := series[t]
+		idx := t % L
+		prevLevel := level
+		level = f.Alpha*(x-seasonal[idx]) + (1-f.Alpha)*(level+trend)
+		trend = f.Beta*(level-prevLevel) + (1-f.Beta)*trend
+		seasonal[idx] = f.Gamma*(x-level) + (1-f.Gamma)*seasonal[idx]
+	}
+	// 样本在以当前时刻结尾的等间隔网格上，第 h 步对应下标 n-1+h，使用该时刻所在相位的季节分量
+	for h := 1; h <= horizon; h++ {
+		path[h-1] = level + float64(h)*trend + seasonal[(n-1+h)%L]
+	}
+	return path
+}
+
+// PercentileForecaster 取窗口内样本的百分位数作为区间内每一步的预测值，适合为周期性峰值预留余量
+type PercentileForecaster struct {
+	Percentile float64 // 百分位（0~100）
+}
+
+func (f *PercentileForecaster) Forecast(series []float64, horizon int) []float64 {
+	return flat(percentile(series, f.Percentile), horizon)
+}
+
+// percentile 计算样本的百分位数（线性插值），p 取值 0~100
+func percentile(series []float64, p float64) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), series...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	if lo < 0 {
+		lo = 0
+	}
+	if hi >= len(sorted) {
+		hi = len(sorted) - 1
+	}
+	return sorted[lo] + (rank-float64(lo))*(sorted[hi]-sorted[lo])
+}
+
+// mean 计算样本均值
+func mean(series []float64) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range series {
+		sum += x
+	}
+	return sum / float64(len(series))
+}
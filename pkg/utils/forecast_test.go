@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// equalPath 逐点比较预测区间，允许浮点误差
+func equalPath(got, want []float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+// repeat 将 pattern 重复 times 次
+func repeat(pattern []float64, times int) []float64 {
+	var series []float64
+	for i := 0; i < times; i++ {
+		series = append(series, pattern...)
+	}
+	return series
+}
+
+func TestEWMAForecaster(t *testing.T) {
+	tests := []struct {
+		name    string
+		alpha   float64
+		series  []float64
+		horizon int
+		want    []float64
+	}{
+		{name: "空序列", alpha: 0.3, series: nil, horizon: 2, want: []float64{0, 0}},
+		{name: "常数序列", alpha: 0.3, series: []float64{5, 5, 5, 5}, horizon: 3, want: []float64{5, 5, 5}},
+		{name: "alpha为1取最后样本", alpha: 1, series: []float64{1, 2, 8}, horizon: 2, want: []float64{8, 8}},
+		{name: "平滑值", alpha: 0.5, series: []float64{0, 4}, horizon: 1, want: []float64{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &EWMAForecaster{Alpha: tt.alpha}
+			if got := f.Forecast(tt.series, tt.horizon); !equalPath(got, tt.want) {
+				t.Errorf("Forecast(%v, %d) = %v, want %v", tt.series, tt.horizon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHoltWintersForecaster(t *testing.T) {
+	pattern := []float64{1, 1, 1, 10}
+	tests := []struct {
+		name    string
+		season  int
+		series  []float64
+		horizon int
+		want    []float64
+	}{
+		{name: "单个样本", season: 4, series: []float64{3}, horizon: 2, want: []float64{3, 3}},
+		{name: "线性趋势", season: 0, series: []float64{1, 2, 3, 4}, horizon: 3, want: []float64{5, 6, 7}},
+		{name: "峰值前的区间", season: 4, series: repeat(pattern, 3), horizon: 3, want: []float64{1, 1, 1}},
+		{name: "区间覆盖峰值", season: 4, series: repeat(pattern, 3), horizon: 4, want: []float64{1, 1, 1, 10}},
+		{name: "样本数不是周期整数倍", season: 4, series: append(repeat(pattern, 3), 1, 1), horizon: 3, want: []float64{1, 10, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &HoltWintersForecaster{Alpha: 0.5, Beta: 0.1, Gamma: 0.1, Season: tt.season}
+			if got := f.Forecast(tt.series, tt.horizon); !equalPath(got, tt.want) {
+				t.Errorf("Forecast(%v, %d) = %v, want %v", tt.series, tt.horizon, got, tt.want)
+			}
+		})
+	}
+}
+
+// rangeValues 构造区间查询的样本对，times 为相对 base 的秒数
+func rangeValues(base time.Time, times []int, values []float64) [][]interface{} {
+	var pairs [][]interface{}
+	for i, sec := range times {
+		ts := float64(base.Add(time.Duration(sec) * time.Second).Unix())
+		pairs = append(pairs, []interface{}{ts, strconv.FormatFloat(values[i], 'f', -1, 64)})
+	}
+	return pairs
+}
+
+func TestGridSeries(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	end := start.Add(50 * time.Second)
+	step := 10 * time.Second
+	tests := []struct {
+		name   string
+		times  []int
+		values []float64
+		want   []float64
+	}{
+		{name: "完整网格", times: []int{0, 10, 20, 30, 40, 50}, values: []float64{1, 2, 3, 4, 5, 6}, want: []float64{1, 2, 3, 4, 5, 6}},
+		{name: "中间缺口线性插值", times: []int{0, 10, 40, 50}, values: []float64{1, 2, 5, 6}, want: []float64{1, 2, 3, 4, 5, 6}},
+		{name: "开头和结尾缺口", times: []int{20, 30}, values: []float64{3, 4}, want: []float64{3, 3, 3, 4, 4, 4}},
+		{name: "时间戳抖动按最近网格点对齐", times: []int{1, 9, 21, 29, 41, 49}, values: []float64{1, 2, 3, 4, 5, 6}, want: []float64{1, 2, 3, 4, 5, 6}},
+		{name: "区间外样本丢弃", times: []int{-10, 0, 50, 60}, values: []float64{9, 1, 6, 9}, want: []float64{1, 2, 3, 4, 5, 6}},
+		{name: "有效样本不足", times: []int{30}, values: []float64{4}, want: []float64{4}},
+		{name: "NaN按缺口处理", times: []int{0, 10, 20, 30, 40, 50}, values: []float64{1, 2, math.NaN(), 4, 5, 6}, want: []float64{1, 2, 3, 4, 5, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gridSeries(rangeValues(start, tt.times, tt.values), start, end, step)
+			if err != nil {
+				t.Fatalf("gridSeries() error = %v", err)
+			}
+			if !equalPath(got, tt.want) {
+				t.Errorf("gridSeries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHoltWintersForecasterWithGap(t *testing.T) {
+	// 三个周期的样本中缺失一个非峰值的点，对齐到网格后峰值仍应预测在原来的相位
+	pattern := []float64{1, 1, 1, 10}
+	series := repeat(pattern, 3)
+	start := time.Unix(1700000000, 0)
+	step := 10 * time.Second
+	end := start.Add(time.Duration(len(series)-1) * step)
+	var times []int
+	var values []float64
+	for i, v := range series {
+		if i == 5 {
+			continue
+		}
+		times = append(times, i*10)
+		values = append(values, v)
+	}
+	grid, err := gridSeries(rangeValues(start, times, values), start, end, step)
+	if err != nil {
+		t.Fatalf("gridSeries() error = %v", err)
+	}
+	f := &HoltWintersForecaster{Alpha: 0.5, Beta: 0.1, Gamma: 0.1, Season: 4}
+	path := f.Forecast(grid, 4)
+	if peak := path[3]; peak < 5 || path[0] > 5 {
+		t.Errorf("Forecast() = %v, want peak at step 4", path)
+	}
+}
+
+func TestPercentileForecaster(t *testing.T) {
+	var hundred []float64
+	for i := 1; i <= 100; i++ {
+		hundred = append(hundred, float64(i))
+	}
+	tests := []struct {
+		name       string
+		percentile float64
+		series     []float64
+		horizon    int
+		want       []float64
+	}{
+		{name: "空序列", percentile: 95, series: nil, horizon: 1, want: []float64{0}},
+		{name: "P95线性插值", percentile: 95, series: hundred, horizon: 2, want: []float64{95.05, 95.05}},
+		{name: "P100为最大值", percentile: 100, series: []float64{3, 9, 1}, horizon: 1, want: []float64{9}},
+		{name: "P0为最小值", percentile: 0, series: []float64{3, 9, 1}, horizon: 1, want: []float64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &PercentileForecaster{Percentile: tt.percentile}
+			if got := f.Forecast(tt.series, tt.horizon); !equalPath(got, tt.want) {
+				t.Errorf("Forecast(%v, %d) = %v, want %v", tt.series, tt.horizon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateHorizon(t *testing.T) {
+	defer func(method string, p float64) {
+		definition.ForecastAggregate, definition.ForecastAggregatePercentileValue = method, p
+	}(definition.ForecastAggregate, definition.ForecastAggregatePercentileValue)
+
+	tests := []struct {
+		name   string
+		method string
+		p      float64
+		path   []float64
+		want   float64
+	}{
+		{name: "空区间", method: definition.ForecastAggregateMax, path: nil, want: 0},
+		{name: "最大值覆盖区间中段峰值", method: definition.ForecastAggregateMax, path: []float64{1, 10, 1}, want: 10},
+		{name: "百分位", method: definition.ForecastAggregatePercentile, p: 50, path: []float64{1, 10, 1}, want: 1},
+		{name: "百分位插值", method: definition.ForecastAggregatePercentile, p: 75, path: []float64{1, 2, 3, 4, 5}, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition.ForecastAggregate, definition.ForecastAggregatePercentileValue = tt.method, tt.p
+			if got := AggregateHorizon(tt.path); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("AggregateHorizon(%v) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
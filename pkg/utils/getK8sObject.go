@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"math"
 )
 
 // Contains 判断字符串 slice 是否包含指定字符串
@@ -69,6 +71,27 @@ func K8sNodesAvailable(isCloud bool) ([]*corev1.Node, error) {
 	return readyNodes, nil
 }
 
+// K8sTierNodesAvailable 返回属于 definition.Tiers 中某一层级的可用节点；云层节点与 K8sNodesAvailable(true) 一样需包含 label role=cloud
+func K8sTierNodesAvailable() ([]*corev1.Node, error) {
+	nodes, err := K8sNodesAvailable(false)
+	if err != nil {
+		return nil, err
+	}
+	var tierNodes []*corev1.Node
+	for _, node := range nodes {
+		switch NodeTier(node.Name) {
+		case "":
+			continue
+		case definition.TierCloud:
+			if node.Labels["role"] != "cloud" {
+				continue
+			}
+		}
+		tierNodes = append(tierNodes, node)
+	}
+	return tierNodes, nil
+}
+
 // K8sNodesAvailableNames 返回所有满足条件的节点名称列表
 func K8sNodesAvailableNames(isCloud bool) ([]string, error) {
 	nodes, err := K8sNodesAvailable(isCloud)
@@ -87,7 +110,7 @@ func K8sNodesAvailableNames(isCloud bool) ([]string, error) {
 
 // GetK8sNodeByName 根据名称查找 k8s Node 对象；找不到时返回错误
 func GetK8sNodeByName(name string) (*corev1.Node, error) {
-	nodes, err := K8sNodesAvailable(true) // 仅筛选云节点
+	nodes, err := K8sTierNodesAvailable()
 	if err != nil {
 		return nil, err
 	}
@@ -99,53 +122,101 @@ func GetK8sNodeByName(name string) (*corev1.Node, error) {
 	return nil, errors.New("name错误")
 }
 
-// GetK8sPodMemoryRequest 获取 Pod 所有容器内存请求总和（字节）
-func GetK8sPodMemoryRequest(pod *corev1.Pod) float64 {
-	var sum float64 = 0
+// podRequest 按 kubelet 准入的口径计算 Pod 的有效请求量（与 k8s.io/component-helpers 的 resourcehelper.PodRequests 一致）：
+// max(业务容器与 sidecar 请求之和, 每个 init 容器运行时的请求) + Spec.Overhead。
+// init 容器依次运行，运行时还需算上在它之前启动的 sidecar（restartPolicy 为 Always 的 init 容器）
+func podRequest(pod *corev1.Pod, name corev1.ResourceName, value func(resource.Quantity) float64) float64 {
+	return podAggregate(pod, name, value, func(container corev1.Container) float64 {
+		return containerQuantity(container.Resources.Requests, name, value)
+	})
+}
+
+// podLimit 按与 podRequest 相同的口径计算 Pod 的最坏情况用量，每个容器取 limit 与 request 的较大值，未设置 limit 的容器按 request 计
+func podLimit(pod *corev1.Pod, name corev1.ResourceName, value func(resource.Quantity) float64) float64 {
+	return podAggregate(pod, name, value, func(container corev1.Container) float64 {
+		return math.Max(containerQuantity(container.Resources.Limits, name, value),
+			containerQuantity(container.Resources.Requests, name, value))
+	})
+}
+
+// containerQuantity 返回资源列表中 name 的值，未设置时为 0
+func containerQuantity(list corev1.ResourceList, name corev1.ResourceName, value func(resource.Quantity) float64) float64 {
+	if qty, exists := list[name]; exists {
+		return value(qty)
+	}
+	return 0
+}
+
+// podAggregate 按 podRequest 的口径汇总每个容器的 amount，并加上 Spec.Overhead
+func podAggregate(pod *corev1.Pod, name corev1.ResourceName, value func(resource.Quantity) float64,
+	amount func(corev1.Container) float64) float64 {
+	var sum float64
 	for _, container := range pod.Spec.Containers {
-		if memQty, exists := container.Resources.Requests[corev1.ResourceMemory]; exists {
-			sum += float64(memQty.Value())
+		sum += amount(container)
+	}
+	var sidecars, initMax float64
+	for _, container := range pod.Spec.InitContainers {
+		if container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			sidecars += amount(container)
+			initMax = math.Max(initMax, sidecars)
+		} else {
+			initMax = math.Max(initMax, sidecars+amount(container))
 		}
 	}
-	return sum
+	total := math.Max(sum+sidecars, initMax)
+	if qty, exists := pod.Spec.Overhead[name]; exists {
+		total += value(qty)
+	}
+	return total
 }
 
-// GetK8sPodMemoryLimits 获取 Pod 所有容器内存限制总和（字节）
+// GetK8sPodMemoryRequest 获取 Pod 的有效内存请求（字节），计算口径见 podRequest
+func GetK8sPodMemoryRequest(pod *corev1.Pod) float64 {
+	return podRequest(pod, corev1.ResourceMemory, quantityToBytes)
+}
+
+// GetK8sPodMemoryLimits 获取 Pod 的最坏情况内存用量（字节），计算口径见 podLimit
 func GetK8sPodMemoryLimits(pod *corev1.Pod) float64 {
-	var sum float64 = 0
-	for _, container := range pod.Spec.Containers {
-		if memQty, exists := container.Resources.Limits[corev1.ResourceMemory]; exists {
-			sum += float64(memQty.Value())
-		}
+	return podLimit(pod, corev1.ResourceMemory, quantityToBytes)
+}
+
+// GetK8sPodStorageRequest 获取 Pod 的有效临时存储请求（字节），计算口径见 podRequest
+func GetK8sPodStorageRequest(pod *corev1.Pod) float64 {
+	return podRequest(pod, corev1.ResourceEphemeralStorage, quantityToBytes)
+}
+
+// GetK8sPodAnnotationQuantity 以 Quantity 解析 Pod 的注解值，未设置或无法解析时返回 0
+func GetK8sPodAnnotationQuantity(pod *corev1.Pod, key string) float64 {
+	value, ok := pod.ObjectMeta.Annotations[key]
+	if !ok {
+		return 0
 	}
-	return sum
+	qty, err := resource.ParseQuantity(value)
+	if err != nil {
+		fmt.Printf("Pod %s 注解 %s=%q 无法解析: %v\n", pod.ObjectMeta.Name, key, value, err)
+		return 0
+	}
+	return qty.AsApproximateFloat64()
 }
 
-// GetK8sPodCpuRequest 获取 Pod 所有容器 CPU 请求总和（毫核）
+// GetK8sPodCpuRequest 获取 Pod 的有效 CPU 请求（毫核），计算口径见 podRequest
 func GetK8sPodCpuRequest(pod *corev1.Pod) float64 {
-	var sum float64 = 0
-	for _, container := range pod.Spec.Containers {
-		if cpuQty, exists := container.Resources.Requests[corev1.ResourceCPU]; exists {
-			sum += float64(cpuQty.MilliValue())
-		}
-	}
-	return sum
+	return podRequest(pod, corev1.ResourceCPU, quantityToMilli)
 }
 
-// GetK8sPodCpuLimits 获取 Pod 所有容器 CPU 限制总和（毫核）
+// GetK8sPodCpuLimits 获取 Pod 的最坏情况 CPU 用量（毫核），计算口径见 podLimit
 func GetK8sPodCpuLimits(pod *corev1.Pod) float64 {
-	var sum float64 = 0
-	for _, container := range pod.Spec.Containers {
-		if cpuQty, exists := container.Resources.Limits[corev1.ResourceCPU]; exists {
-			sum += float64(cpuQty.MilliValue())
-		}
-	}
-	return sum
+	return podLimit(pod, corev1.ResourceCPU, quantityToMilli)
+}
+
+// IsPodTerminated 判断 Pod 是否已结束（Succeeded 或 Failed），已结束的 Pod 不再占用节点资源
+func IsPodTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
 }
 
-// GetNodePods 获取所有云节点上 namespace 为 "k8s" 且状态为 Running 的 Pod，并转换为自定义 Pod 对象
+// GetNodePods 获取所有层级节点上未结束的 Pod（不限命名空间，与 kubelet 准入的统计口径一致），并转换为自定义 Pod 对象
 func GetNodePods() (map[string][]*definition.Pod, error) {
-	nodes, err := K8sNodesAvailable(true)
+	nodes, err := K8sTierNodesAvailable()
 	if err != nil {
 		return nil, err
 	}
@@ -160,9 +231,10 @@ func GetNodePods() (map[string][]*definition.Pod, error) {
 			return nil, err
 		}
 		var pods []*definition.Pod
-		for _, pod := range podsList.Items {
-			if pod.Namespace == "k8s" && pod.Status.Phase == corev1.PodRunning {
-				pods = append(pods, ConvertK8sPodToMyPod(&pod))
+		for i := range podsList.Items {
+			pod := &podsList.Items[i]
+			if !IsPodTerminated(pod) {
+				pods = append(pods, ConvertK8sPodToMyPod(pod))
 			}
 		}
 		nodePods[node.Name] = pods
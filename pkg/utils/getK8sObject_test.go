@@ -0,0 +1,138 @@
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"testing"
+)
+
+// requests 构造只有请求量的容器
+func requests(cpu, mem string) corev1.Container {
+	return corev1.Container{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(mem),
+	}}}
+}
+
+// sidecar 构造 restartPolicy 为 Always 的 init 容器
+func sidecar(cpu, mem string) corev1.Container {
+	container := requests(cpu, mem)
+	always := corev1.ContainerRestartPolicyAlways
+	container.RestartPolicy = &always
+	return container
+}
+
+func TestGetK8sPodRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    corev1.PodSpec
+		wantCPU float64
+		wantMem float64
+	}{
+		{
+			name:    "业务容器求和",
+			spec:    corev1.PodSpec{Containers: []corev1.Container{requests("500m", "1Gi"), requests("250m", "512Mi")}},
+			wantCPU: 750,
+			wantMem: 1536 << 20,
+		},
+		{
+			name: "init容器更大时取init容器",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{requests("2", "256Mi"), requests("100m", "4Gi")},
+				Containers:     []corev1.Container{requests("500m", "1Gi")},
+			},
+			wantCPU: 2000,
+			wantMem: 4 << 30,
+		},
+		{
+			name: "sidecar与业务容器同时运行",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{sidecar("200m", "128Mi"), requests("1", "256Mi")},
+				Containers:     []corev1.Container{requests("500m", "1Gi")},
+			},
+			wantCPU: 1200,
+			wantMem: 1152 << 20,
+		},
+		{
+			name: "Overhead",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{requests("500m", "1Gi")},
+				Overhead: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("160Mi"),
+				},
+			},
+			wantCPU: 750,
+			wantMem: 1184 << 20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Spec: tt.spec}
+			if got := GetK8sPodCpuRequest(pod); got != tt.wantCPU {
+				t.Errorf("GetK8sPodCpuRequest() = %v, want %v", got, tt.wantCPU)
+			}
+			if got := GetK8sPodMemoryRequest(pod); got != tt.wantMem {
+				t.Errorf("GetK8sPodMemoryRequest() = %v, want %v", got, tt.wantMem)
+			}
+		})
+	}
+}
+
+// limits 构造设置了 limit 的容器
+func limits(container corev1.Container, cpu, mem string) corev1.Container {
+	container.Resources.Limits = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(mem),
+	}
+	return container
+}
+
+func TestGetK8sPodLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    corev1.PodSpec
+		wantCPU float64
+		wantMem float64
+	}{
+		{
+			name:    "未设置limit按request计",
+			spec:    corev1.PodSpec{Containers: []corev1.Container{limits(requests("500m", "1Gi"), "1", "2Gi"), requests("250m", "512Mi")}},
+			wantCPU: 1250,
+			wantMem: 2560 << 20,
+		},
+		{
+			name: "sidecar的limit与业务容器同时计入",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{limits(sidecar("200m", "128Mi"), "500m", "256Mi")},
+				Containers:     []corev1.Container{limits(requests("500m", "1Gi"), "1", "2Gi")},
+			},
+			wantCPU: 1500,
+			wantMem: 2304 << 20,
+		},
+		{
+			name: "init容器峰值与Overhead",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{limits(requests("1", "256Mi"), "3", "512Mi")},
+				Containers:     []corev1.Container{limits(requests("500m", "1Gi"), "1", "2Gi")},
+				Overhead: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("160Mi"),
+				},
+			},
+			wantCPU: 3250,
+			wantMem: 2208 << 20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Spec: tt.spec}
+			if got := GetK8sPodCpuLimits(pod); got != tt.wantCPU {
+				t.Errorf("GetK8sPodCpuLimits() = %v, want %v", got, tt.wantCPU)
+			}
+			if got := GetK8sPodMemoryLimits(pod); got != tt.wantMem {
+				t.Errorf("GetK8sPodMemoryLimits() = %v, want %v", got, tt.wantMem)
+			}
+		})
+	}
+}
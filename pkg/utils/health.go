@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NodeHealthChecker 根据节点条件与 node-exporter 内核信号定期评估节点健康
+type NodeHealthChecker struct {
+	mu     sync.RWMutex
+	health map[string]*definition.NodeHealth
+}
+
+// NewNodeHealthChecker 创建健康检查器，首次 Refresh 之前所有节点视为健康
+func NewNodeHealthChecker() *NodeHealthChecker {
+	return &NodeHealthChecker{health: make(map[string]*definition.NodeHealth)}
+}
+
+// Get 返回节点的健康评估结果，尚未评估时返回 nil
+func (h *NodeHealthChecker) Get(name string) *definition.NodeHealth {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.health[name]
+}
+
+// Refresh 拉取节点条件和快照中的 OOM kill、重启次数，重新计算所有节点的健康分
+func (h *NodeHealthChecker) Refresh() error {
+	nodesList, err := definition.ClientSet.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	snapshot := Cache.Snapshot()
+	kernel := make(map[string]map[string]float64)
+	for _, signal := range []string{definition.HealthOOMKills, definition.HealthReboots} {
+		values, err := snapshot.NodeValues(signal)
+		if err != nil {
+			fmt.Printf("获取节点健康信号 %s 错误: %v\n", signal, err)
+			continue
+		}
+		kernel[signal] = values
+	}
+
+	health := make(map[string]*definition.NodeHealth)
+	now := time.Now()
+	for i := range nodesList.Items {
+		node := &nodesList.Items[i]
+		signals := make(map[string]float64)
+		for _, cond := range node.Status.Conditions {
+			if cond.Status == corev1.ConditionTrue {
+				signals[string(cond.Type)] = 1
+			}
+		}
+		for signal, values := range kernel {
+			if value, ok := values[node.Name]; ok {
+				signals[signal] = value
+			}
+		}
+		result := EvaluateNodeHealth(signals)
+		result.UpdatedAt = now
+		health[node.Name] = result
+	}
+
+	h.mu.Lock()
+	h.health = health
+	h.mu.Unlock()
+	return nil
+}
+
+// EvaluateNodeHealth 按 definition.HealthPolicies 评估节点健康，signals 为 信号 -> 值
+func EvaluateNodeHealth(signals map[string]float64) *definition.NodeHealth {
+	result := &definition.NodeHealth{Score: 100}
+	for _, policy := range definition.HealthPolicies {
+		value, ok := signals[policy.Signal]
+		if !ok || value < policy.Threshold {
+			continue
+		}
+		if policy.Block {
+			result.Blocked = true
+			result.Reasons = append(result.Reasons, fmt.Sprintf("%s=%g（过滤）", policy.Signal, value))
+			continue
+		}
+		result.Score -= policy.Penalty
+		result.Reasons = append(result.Reasons, fmt.Sprintf("%s=%g（-%g）", policy.Signal, value, policy.Penalty))
+	}
+	if result.Score < 0 {
+		result.Score = 0
+	}
+	return result
+}
+
+// Report 返回所有节点健康分及原因的调试输出，按节点名称排序
+func (h *NodeHealthChecker) Report() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	names := make([]string, 0, len(h.health))
+	for name := range h.health {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	report := "=== 节点健康 ===\n"
+	for _, name := range names {
+		result := h.health[name]
+		report += fmt.Sprintf("%s: %.0f", name, result.Score)
+		if result.Blocked {
+			report += " 不可调度"
+		}
+		if len(result.Reasons) > 0 {
+			report += fmt.Sprintf(" %v", result.Reasons)
+		}
+		report += "\n"
+	}
+	return report
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+	"sync"
+)
+
+// imageState 镜像大小及缓存了该镜像的节点
+type imageState struct {
+	size  float64
+	nodes map[string]bool
+}
+
+// NodeImageCache 定期从 Node.Status.Images 汇总各节点缓存的镜像
+type NodeImageCache struct {
+	mu         sync.RWMutex
+	images     map[string]*imageState // 规范化的镜像名称 -> 状态
+	totalNodes int
+}
+
+// NewNodeImageCache 创建节点镜像缓存，首次 Refresh 之前所有节点视为没有缓存镜像
+func NewNodeImageCache() *NodeImageCache {
+	return &NodeImageCache{images: make(map[string]*imageState)}
+}
+
+// normalizedImageName 没有 tag 和 digest 的镜像补全为 :latest，与 kubelet 上报的名称一致
+func normalizedImageName(name string) string {
+	if strings.LastIndex(name, ":") <= strings.LastIndex(name, "/") && !strings.Contains(name, "@") {
+		name = name + ":latest"
+	}
+	return name
+}
+
+// Refresh 拉取所有层级节点的镜像列表
+func (c *NodeImageCache) Refresh() error {
+	nodesList, err := definition.ClientSet.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	images := make(map[string]*imageState)
+	total := 0
+	for i := range nodesList.Items {
+		node := &nodesList.Items[i]
+		if !InAnyTier(node.Name) {
+			continue
+		}
+		total++
+		for _, image := range node.Status.Images {
+			for _, name := range image.Names {
+				name = normalizedImageName(name)
+				state, ok := images[name]
+				if !ok {
+					state = &imageState{size: float64(image.SizeBytes), nodes: make(map[string]bool)}
+					images[name] = state
+				}
+				state.nodes[node.Name] = true
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.images = images
+	c.totalNodes = total
+	c.mu.Unlock()
+	return nil
+}
+
+// ImageScore 返回节点上已缓存的 Pod 镜像的 大小 × 分布比例 之和（字节），分布比例为缓存该镜像的节点占比，
+// 避免热门镜像把 Pod 都吸引到同一批节点
+func (c *NodeImageCache) ImageScore(nodeName string, pod *corev1.Pod) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.totalNodes == 0 {
+		return 0
+	}
+	var sum float64
+	for _, container := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		state, ok := c.images[normalizedImageName(container.Image)]
+		if !ok || !state.nodes[nodeName] {
+			continue
+		}
+		sum += state.size * float64(len(state.nodes)) / float64(c.totalNodes)
+	}
+	return sum
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net"
+	"sync"
+	"time"
+)
+
+// instanceLookupTTL 节点地址表和 node_uname_info 对应表的刷新间隔
+const instanceLookupTTL = 5 * time.Minute
+
+// instanceMapper 按 definition.InstanceMapping 将 Prometheus 序列的标签映射为节点名称
+type instanceMapper struct {
+	mu        sync.Mutex
+	byIP      map[string]string // IP -> 节点名称
+	byUname   map[string]string // instance -> 节点名称（node_uname_info 的 nodename）
+	names     map[string]string // 已知的节点名称
+	updatedAt time.Time
+	unmapped  map[string]string // 无法映射的 instance -> 原因
+}
+
+var mapper = &instanceMapper{unmapped: make(map[string]string)}
+
+// instanceHost 去掉 instance 中的端口，例如 "10.0.0.5:9100" -> "10.0.0.5"
+func instanceHost(instance string) string {
+	if host, _, err := net.SplitHostPort(instance); err == nil {
+		return host
+	}
+	return instance
+}
+
+// refreshLocked 刷新节点名称、地址表和 node_uname_info 对应表，调用方需持有 mu
+func (m *instanceMapper) refreshLocked() {
+	byIP := make(map[string]string)
+	names := make(map[string]string)
+	for name, ip := range definition.NodeIps {
+		byIP[ip] = name
+		names[name] = name
+	}
+	if definition.ClientSet != nil {
+		nodes, err := definition.ClientSet.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			fmt.Printf("获取节点地址错误: %v\n", err)
+		} else {
+			for _, n := range nodes.Items {
+				names[n.Name] = n.Name
+				for _, addr := range n.Status.Addresses {
+					if addr.Type == corev1.NodeInternalIP || addr.Type == corev1.NodeExternalIP {
+						byIP[addr.Address] = n.Name
+					}
+				}
+			}
+		}
+	}
+	m.byIP = byIP
+	m.names = names
+
+	if definition.InstanceMapping == definition.InstanceMappingUname {
+		results, err := performQuery(definition.NodeUnameURL)
+		if err != nil {
+			fmt.Printf("获取 node_uname_info 错误: %v\n", err)
+		} else {
+			byUname := make(map[string]string)
+			for _, item := range results {
+				byUname[item.Metric["instance"]] = item.Metric["nodename"]
+			}
+			m.byUname = byUname
+		}
+	}
+	m.updatedAt = time.Now()
+}
+
+// nodeName 返回序列对应的节点名称；无法映射或映射结果不是已知节点时记录诊断信息并返回 false
+func (m *instanceMapper) nodeName(metric map[string]string) (string, bool) {
+	instance := metric["instance"]
+	var name string
+	switch definition.InstanceMapping {
+	case definition.InstanceMappingLabel:
+		if name = metric[definition.InstanceLabel]; name == "" {
+			m.reportUnmapped(instance, fmt.Sprintf("缺少标签 %s", definition.InstanceLabel))
+			return "", false
+		}
+	case definition.InstanceMappingAddress:
+		host := instanceHost(instance)
+		var ok bool
+		if name, ok = m.lookup(func() map[string]string { return m.byIP }, host); !ok {
+			m.reportUnmapped(instance, fmt.Sprintf("地址 %s 不属于任何节点", host))
+			return "", false
+		}
+	case definition.InstanceMappingUname:
+		var ok bool
+		if name, ok = m.lookup(func() map[string]string { return m.byUname }, instance); !ok {
+			m.reportUnmapped(instance, "node_uname_info 中没有对应的 nodename")
+			return "", false
+		}
+	default:
+		if name = instance; name == "" {
+			m.reportUnmapped(instance, "缺少 instance 标签")
+			return "", false
+		}
+	}
+	// 映射结果必须是已知节点，例如 instance 模式下的 "10.0.0.5:9100" 会在这里记录，而不是被层级过滤静默丢弃
+	if _, ok := m.lookup(func() map[string]string { return m.names }, name); !ok {
+		m.reportUnmapped(instance, fmt.Sprintf("映射结果 %s 不是已知节点", name))
+		return "", false
+	}
+	m.clearUnmapped(instance)
+	return name, true
+}
+
+// lookup 在对应表中查找 key，表过期或未命中时刷新一次后重试
+func (m *instanceMapper) lookup(table func() map[string]string, key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if time.Since(m.updatedAt) > instanceLookupTTL {
+		m.refreshLocked()
+	}
+	if name, ok := table()[key]; ok && name != "" {
+		return name, true
+	}
+	// 未命中时可能有新节点加入，最多每 10 秒强制刷新一次
+	if time.Since(m.updatedAt) > 10*time.Second {
+		m.refreshLocked()
+		if name, ok := table()[key]; ok && name != "" {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// reportUnmapped 记录无法映射的 instance，同一 instance 只打印一次
+func (m *instanceMapper) reportUnmapped(instance, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.unmapped[instance]; !ok {
+		fmt.Printf("无法将 Prometheus instance %q 映射到节点（%s 模式）：%s\n", instance, definition.InstanceMapping, reason)
+	}
+	m.unmapped[instance] = reason
+}
+
+// clearUnmapped 映射成功后删除 instance 之前的无法映射记录，例如节点地址后来才出现
+func (m *instanceMapper) clearUnmapped(instance string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.unmapped[instance]; ok {
+		fmt.Printf("Prometheus instance %q 已可以映射到节点\n", instance)
+		delete(m.unmapped, instance)
+	}
+}
+
+// UnmappedInstances 返回无法映射到节点的 instance 及原因
+func UnmappedInstances() map[string]string {
+	mapper.mu.Lock()
+	defer mapper.mu.Unlock()
+	unmapped := make(map[string]string, len(mapper.unmapped))
+	for instance, reason := range mapper.unmapped {
+		unmapped[instance] = reason
+	}
+	return unmapped
+}
+
+// MetricNodeName 返回 Prometheus 序列对应的节点名称
+func MetricNodeName(metric map[string]string) (string, bool) {
+	return mapper.nodeName(metric)
+}
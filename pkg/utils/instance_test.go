@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"testing"
+	"time"
+)
+
+func TestInstanceHost(t *testing.T) {
+	tests := []struct {
+		instance string
+		want     string
+	}{
+		{"10.0.0.5:9100", "10.0.0.5"},
+		{"10.0.0.5", "10.0.0.5"},
+		{"[fd00::5]:9100", "fd00::5"},
+		{"node1:9100", "node1"},
+		{"node1", "node1"},
+	}
+	for _, tt := range tests {
+		if got := instanceHost(tt.instance); got != tt.want {
+			t.Errorf("instanceHost(%q) = %q, want %q", tt.instance, got, tt.want)
+		}
+	}
+}
+
+// newTestMapper 构造已填充对应表的 instanceMapper，updatedAt 为当前时间，查找时不会访问集群或 Prometheus
+func newTestMapper() *instanceMapper {
+	return &instanceMapper{
+		byIP:      map[string]string{"10.0.0.5": "node1", "10.0.0.6": "node2"},
+		byUname:   map[string]string{"10.0.0.5:9100": "node1", "10.0.0.7:9100": "ghost"},
+		names:     map[string]string{"node1": "node1", "node2": "node2"},
+		updatedAt: time.Now(),
+		unmapped:  make(map[string]string),
+	}
+}
+
+func TestInstanceMapperNodeName(t *testing.T) {
+	defer func(mapping, label string) {
+		definition.InstanceMapping, definition.InstanceLabel = mapping, label
+	}(definition.InstanceMapping, definition.InstanceLabel)
+	definition.InstanceLabel = "node"
+
+	tests := []struct {
+		name         string
+		mapping      string
+		metric       map[string]string
+		want         string
+		wantOK       bool
+		wantUnmapped string // 期望记录为无法映射的 instance，为空表示不记录
+	}{
+		{name: "instance即节点名称", mapping: definition.InstanceMappingInstance,
+			metric: map[string]string{"instance": "node1"}, want: "node1", wantOK: true},
+		{name: "instance带端口不是已知节点", mapping: definition.InstanceMappingInstance,
+			metric: map[string]string{"instance": "10.0.0.5:9100"}, wantUnmapped: "10.0.0.5:9100"},
+		{name: "缺少instance标签", mapping: definition.InstanceMappingInstance,
+			metric: map[string]string{}, wantUnmapped: ""},
+		{name: "标签映射", mapping: definition.InstanceMappingLabel,
+			metric: map[string]string{"instance": "10.0.0.6:9100", "node": "node2"}, want: "node2", wantOK: true},
+		{name: "缺少节点标签", mapping: definition.InstanceMappingLabel,
+			metric: map[string]string{"instance": "10.0.0.6:9100"}, wantUnmapped: "10.0.0.6:9100"},
+		{name: "标签值不是已知节点", mapping: definition.InstanceMappingLabel,
+			metric: map[string]string{"instance": "10.0.0.6:9100", "node": "node9"}, wantUnmapped: "10.0.0.6:9100"},
+		{name: "地址映射去掉端口", mapping: definition.InstanceMappingAddress,
+			metric: map[string]string{"instance": "10.0.0.5:9100"}, want: "node1", wantOK: true},
+		{name: "地址不属于任何节点", mapping: definition.InstanceMappingAddress,
+			metric: map[string]string{"instance": "10.0.0.9:9100"}, wantUnmapped: "10.0.0.9:9100"},
+		{name: "uname映射", mapping: definition.InstanceMappingUname,
+			metric: map[string]string{"instance": "10.0.0.5:9100"}, want: "node1", wantOK: true},
+		{name: "uname中没有对应的nodename", mapping: definition.InstanceMappingUname,
+			metric: map[string]string{"instance": "10.0.0.6:9100"}, wantUnmapped: "10.0.0.6:9100"},
+		{name: "uname主机名不是已知节点", mapping: definition.InstanceMappingUname,
+			metric: map[string]string{"instance": "10.0.0.7:9100"}, wantUnmapped: "10.0.0.7:9100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition.InstanceMapping = tt.mapping
+			m := newTestMapper()
+			got, ok := m.nodeName(tt.metric)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("nodeName(%v) = (%q, %v), want (%q, %v)", tt.metric, got, ok, tt.want, tt.wantOK)
+			}
+			if tt.wantOK {
+				if len(m.unmapped) != 0 {
+					t.Errorf("unmapped = %v, want empty", m.unmapped)
+				}
+				return
+			}
+			if _, recorded := m.unmapped[tt.wantUnmapped]; !recorded || len(m.unmapped) != 1 {
+				t.Errorf("unmapped = %v, want only %q", m.unmapped, tt.wantUnmapped)
+			}
+		})
+	}
+}
+
+func TestInstanceMapperReportUnmappedKeepsLatestReason(t *testing.T) {
+	m := newTestMapper()
+	m.reportUnmapped("10.0.0.9:9100", "first")
+	m.reportUnmapped("10.0.0.9:9100", "second")
+	if got := m.unmapped["10.0.0.9:9100"]; got != "second" || len(m.unmapped) != 1 {
+		t.Errorf("unmapped = %v, want 10.0.0.9:9100 -> second", m.unmapped)
+	}
+}
+
+func TestInstanceMapperClearsUnmappedAfterMapping(t *testing.T) {
+	defer func(mapping string) { definition.InstanceMapping = mapping }(definition.InstanceMapping)
+	definition.InstanceMapping = definition.InstanceMappingAddress
+
+	m := newTestMapper()
+	metric := map[string]string{"instance": "10.0.0.9:9100"}
+	if _, ok := m.nodeName(metric); ok {
+		t.Fatal("nodeName() should fail before the node address is known")
+	}
+	if _, recorded := m.unmapped["10.0.0.9:9100"]; !recorded {
+		t.Fatalf("unmapped = %v, want 10.0.0.9:9100 recorded", m.unmapped)
+	}
+
+	// 节点地址出现后映射成功，之前的记录应被删除
+	m.byIP["10.0.0.9"] = "node2"
+	if got, ok := m.nodeName(metric); !ok || got != "node2" {
+		t.Fatalf("nodeName() = (%q, %v), want (node2, true)", got, ok)
+	}
+	if len(m.unmapped) != 0 {
+		t.Errorf("unmapped = %v, want empty after successful mapping", m.unmapped)
+	}
+}
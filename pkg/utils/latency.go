@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"encoding/json"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// latencyCache 缓存的时延矩阵，源节点 -> 目标 -> 往返时延（秒）
+var latencyCache = struct {
+	mu      sync.Mutex
+	matrix  map[string]map[string]float64
+	fetched time.Time
+}{}
+
+// GetLatencyMatrix 返回时延矩阵，超过 definition.LatencyCacheTTL 时重新获取；获取失败时返回错误和上一次的矩阵
+func GetLatencyMatrix() (map[string]map[string]float64, error) {
+	latencyCache.mu.Lock()
+	defer latencyCache.mu.Unlock()
+	if latencyCache.matrix != nil && time.Since(latencyCache.fetched) < definition.LatencyCacheTTL {
+		return latencyCache.matrix, nil
+	}
+	var matrix map[string]map[string]float64
+	var err error
+	switch definition.LatencyBackend {
+	case definition.LatencyBackendPrometheus:
+		matrix, err = httpGetLatencyMatrix()
+	case definition.LatencyBackendStatic:
+		matrix, err = loadLatencyFile(definition.LatencyFile)
+	default:
+		err = fmt.Errorf("不支持的时延数据源: %s", definition.LatencyBackend)
+	}
+	if err != nil {
+		return latencyCache.matrix, err
+	}
+	latencyCache.matrix = matrix
+	latencyCache.fetched = time.Now()
+	return matrix, nil
+}
+
+// httpGetLatencyMatrix 从 blackbox-exporter 的探测指标构建时延矩阵
+func httpGetLatencyMatrix() (map[string]map[string]float64, error) {
+	results, err := performQuery(definition.NodeLatencyURL)
+	if err != nil {
+		return nil, err
+	}
+	matrix := make(map[string]map[string]float64)
+	for _, item := range results {
+		source, target := item.Metric[definition.LatencySourceLabel], item.Metric[definition.LatencyTargetLabel]
+		if source == "" || target == "" || len(item.Value) < 2 {
+			continue
+		}
+		raw, ok := item.Value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value type for %s -> %s: %T", source, target, item.Value[1])
+		}
+		val, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse value for %s -> %s: %v", source, target, err)
+		}
+		if matrix[source] == nil {
+			matrix[source] = make(map[string]float64)
+		}
+		matrix[source][target] = val
+	}
+	return matrix, nil
+}
+
+// loadLatencyFile 从 JSON 文件读取时延矩阵
+func loadLatencyFile(filename string) (map[string]map[string]float64, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取时延矩阵文件错误: %v", err)
+	}
+	matrix := make(map[string]map[string]float64)
+	if err := json.Unmarshal(raw, &matrix); err != nil {
+		return nil, fmt.Errorf("解析时延矩阵文件错误: %v", err)
+	}
+	return matrix, nil
+}
+
+// NodeRTT 返回节点到目标的往返时延（秒），目标为节点时也按该节点的 IP 查找（blackbox 通常以地址探测）
+func NodeRTT(matrix map[string]map[string]float64, node, target string) (float64, bool) {
+	if node == target {
+		return 0, true
+	}
+	if rtt, ok := matrix[node][target]; ok {
+		return rtt, true
+	}
+	if ip, ok := definition.NodeIps[target]; ok {
+		for probed, rtt := range matrix[node] {
+			if probed == ip || instanceHost(probed) == ip {
+				return rtt, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// GetPodLatencyRequest 解析 Pod 的时延目标和最大往返时延，最大时延无法解析时只打分不过滤
+func GetPodLatencyRequest(pod *corev1.Pod) (string, time.Duration) {
+	target := pod.ObjectMeta.Annotations[definition.PodLatencyTargetAnnotation]
+	value, ok := pod.ObjectMeta.Annotations[definition.PodMaxRTTAnnotation]
+	if target == "" || !ok {
+		return target, 0
+	}
+	maxRTT, err := time.ParseDuration(value)
+	if err != nil {
+		fmt.Printf("Pod %s 注解 %s=%q 无法解析: %v\n", pod.ObjectMeta.Name, definition.PodMaxRTTAnnotation, value, err)
+		return target, 0
+	}
+	return target, maxRTT
+}
@@ -2,11 +2,9 @@ package utils
 
 import (
 	"MBCTG/pkg/definition"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"math"
 	"net/url"
 	"os"
 	"strconv"
@@ -15,55 +13,59 @@ import (
 
 // MetricResult matches individual Prometheus metric entries.
 type MetricResult struct {
-	Metric struct {
-		Instance string `json:"instance"`
-	} `json:"metric"`
-	Value []interface{} `json:"value"` // [<timestamp>, "<value-as-string>"]
+	Metric map[string]string `json:"metric"` // 序列标签
+	Value  []interface{}     `json:"value"`  // [<timestamp>, "<value-as-string>"]
+	Values [][]interface{}   `json:"values"` // range query: [[<timestamp>, "<value-as-string>"], ...]
 }
 
 type queryResponse struct {
-	Status string `json:"status"`
-	Data   struct {
+	Status    string   `json:"status"`
+	ErrorType string   `json:"errorType"`
+	Error     string   `json:"error"`
+	Warnings  []string `json:"warnings"`
+	Data      struct {
 		Result []MetricResult `json:"result"`
 	} `json:"data"`
 }
 
 // performQuery 处理promQL
 func performQuery(promql string) ([]MetricResult, error) {
-	endpoint := fmt.Sprintf("http://%s:%d/api/v1/query", definition.MasterIp, definition.PrometheusPort)
 	params := url.Values{}
 	params.Set("query", promql)
-	fullURL := endpoint + "?" + params.Encode()
-
-	resp, err := http.Get(fullURL)
-	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %v", err)
-	}
-	defer resp.Body.Close()
+	return doPromRequest("query", params)
+}
 
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("prometheus returned status %d: %s", resp.StatusCode, string(body))
-	}
+// performRangeQuery 处理区间promQL，返回 [start, end] 内每隔 step 的样本
+func performRangeQuery(promql string, start, end time.Time, step time.Duration) ([]MetricResult, error) {
+	params := url.Values{}
+	params.Set("query", promql)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	return doPromRequest("query_range", params)
+}
 
-	var qr queryResponse
-	if err := json.Unmarshal(body, &qr); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %v", err)
-	}
-	if qr.Status != "success" {
-		return nil, fmt.Errorf("prometheus status: %s", qr.Status)
-	}
-	return qr.Data.Result, nil
+// NodeSample 节点监控样本及其时间戳
+type NodeSample struct {
+	Value     float64
+	Timestamp time.Time
 }
 
-// parseResultsToMap Node监控数据转为map
-func parseResultsToMap(results []MetricResult) (map[string]float64, error) {
-	nodeMonitor := make(map[string]float64)
+// parseResultsToSamples Node监控数据转为带时间戳的map
+func parseResultsToSamples(results []MetricResult) (map[string]NodeSample, error) {
+	nodeSamples := make(map[string]NodeSample)
 	for _, item := range results {
-		name := item.Metric.Instance
-		if !Contains(definition.CloudNodes, name) {
+		name, ok := MetricNodeName(item.Metric)
+		if !ok || !InAnyTier(name) {
 			continue
 		}
+		if len(item.Value) < 2 {
+			return nil, fmt.Errorf("unexpected sample for %s: %v", name, item.Value)
+		}
+		ts, ok := item.Value[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected timestamp type for %s: %T", name, item.Value[0])
+		}
 		raw, ok := item.Value[1].(string)
 		if !ok {
 			return nil, fmt.Errorf("unexpected value type for %s: %T", name, item.Value[1])
@@ -72,21 +74,64 @@ func parseResultsToMap(results []MetricResult) (map[string]float64, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse value for %s: %v", name, err)
 		}
-		nodeMonitor[name] = val
+		sec, frac := math.Modf(ts)
+		nodeSamples[name] = NodeSample{Value: val, Timestamp: time.Unix(int64(sec), int64(frac*1e9))}
+	}
+	return nodeSamples, nil
+}
+
+// parseResultsToMap Node监控数据转为map
+func parseResultsToMap(results []MetricResult) (map[string]float64, error) {
+	nodeSamples, err := parseResultsToSamples(results)
+	if err != nil {
+		return nil, err
+	}
+	nodeMonitor := make(map[string]float64)
+	for name, sample := range nodeSamples {
+		nodeMonitor[name] = sample.Value
 	}
 	return nodeMonitor, nil
 }
 
-// HttpGetNodeMonitor 监控节点cpu和内存使用量
-func HttpGetNodeMonitor(req string) (map[string]float64, error) {
-	var promql string
+// nodeMonitorQuery 返回节点监控项对应的promQL
+func nodeMonitorQuery(req string) (string, error) {
 	switch req {
 	case "mem":
-		promql = definition.NodeMemURL
+		return definition.NodeMemURL, nil
 	case "cpu":
-		promql = definition.NodeCpuURL
+		return definition.NodeCpuURL, nil
+	case "storage":
+		return definition.NodeStorageURL, nil
+	case "net":
+		return definition.NodeNetURL, nil
+	case "net-speed":
+		return definition.NodeNetSpeedURL, nil
+	case "disk-io":
+		return definition.NodeDiskIOURL, nil
+	case "disk-iops":
+		return definition.NodeDiskIOPSURL, nil
+	case definition.PressureCPU:
+		return definition.NodePressureCPUURL, nil
+	case definition.PressureMemory:
+		return definition.NodePressureMemoryURL, nil
+	case definition.PressureIO:
+		return definition.NodePressureIOURL, nil
+	case definition.PressureLoad:
+		return definition.NodeLoadPerCoreURL, nil
+	case definition.HealthOOMKills:
+		return definition.NodeOOMKillsURL, nil
+	case definition.HealthReboots:
+		return definition.NodeRebootsURL, nil
 	default:
-		return nil, errors.New("unsupported request type")
+		return "", errors.New("unsupported request type")
+	}
+}
+
+// HttpGetNodeMonitor 监控节点cpu、内存、根文件系统使用量、网卡吞吐、网卡速率、磁盘繁忙比例、IOPS、压力及健康信号
+func HttpGetNodeMonitor(req string) (map[string]float64, error) {
+	promql, err := nodeMonitorQuery(req)
+	if err != nil {
+		return nil, err
 	}
 	results, err := performQuery(promql)
 	if err != nil {
@@ -95,6 +140,188 @@ func HttpGetNodeMonitor(req string) (map[string]float64, error) {
 	return parseResultsToMap(results)
 }
 
+// HttpGetNodeSamples 监控节点cpu、内存等使用量，保留样本时间戳
+func HttpGetNodeSamples(req string) (map[string]NodeSample, error) {
+	promql, err := nodeMonitorQuery(req)
+	if err != nil {
+		return nil, err
+	}
+	results, err := performQuery(promql)
+	if err != nil {
+		return nil, err
+	}
+	return parseResultsToSamples(results)
+}
+
+// HttpGetNodeLastScrape 获取 node-exporter 在每个节点上最近一次成功采集的时间
+func HttpGetNodeLastScrape() (map[string]time.Time, error) {
+	results, err := performQuery(definition.NodeLastScrapeURL)
+	if err != nil {
+		return nil, err
+	}
+	nodeSamples, err := parseResultsToSamples(results)
+	if err != nil {
+		return nil, err
+	}
+	lastScrape := make(map[string]time.Time)
+	for name, sample := range nodeSamples {
+		sec, frac := math.Modf(sample.Value)
+		lastScrape[name] = time.Unix(int64(sec), int64(frac*1e9))
+	}
+	return lastScrape, nil
+}
+
+// HttpGetNodeMonitorRange 获取节点监控项在 [start, end] 内的历史样本，按 gridSeries 对齐到以 end 结尾、间隔为 step 的时间网格
+func HttpGetNodeMonitorRange(req string, start, end time.Time, step time.Duration) (map[string][]float64, error) {
+	promql, err := nodeMonitorQuery(req)
+	if err != nil {
+		return nil, err
+	}
+	results, err := performRangeQuery(promql, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	nodeSeries := make(map[string][]float64)
+	for _, item := range results {
+		name, ok := MetricNodeName(item.Metric)
+		if !ok || !InAnyTier(name) {
+			continue
+		}
+		series, err := gridSeries(item.Values, start, end, step)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse series for %s: %v", name, err)
+		}
+		nodeSeries[name] = series
+	}
+	return nodeSeries, nil
+}
+
+// parseSeries 解析区间查询的样本值，丢弃 NaN 和 Inf
+func parseSeries(values [][]interface{}) ([]float64, error) {
+	series := make([]float64, 0, len(values))
+	for _, pair := range values {
+		if len(pair) < 2 {
+			continue
+		}
+		raw, ok := pair[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value type: %T", pair[1])
+		}
+		val, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			continue
+		}
+		series = append(series, val)
+	}
+	return series, nil
+}
+
+// gridSeries 按样本时间戳将区间查询结果放到 end-k*step（k=0,1,...，不早于 start）组成的时间网格上，按时间升序返回，
+// 最后一个点对应 end。缺失的步长（查询结果中没有该点，或样本为 NaN、Inf）取前后样本的线性插值，
+// 开头和结尾的缺口分别用第一个和最后一个有效样本填充，使季节分量的相位不受采集中断影响；有效样本不足两个时只返回有效样本
+func gridSeries(values [][]interface{}, start, end time.Time, step time.Duration) ([]float64, error) {
+	if step <= 0 || end.Before(start) {
+		return parseSeries(values)
+	}
+	n := int(end.Sub(start)/step) + 1
+	first := end.Add(-time.Duration(n-1) * step)
+	grid := make([]float64, n)
+	known := make([]bool, n)
+	var count int
+	for _, pair := range values {
+		if len(pair) < 2 {
+			continue
+		}
+		ts, ok := pair[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected timestamp type: %T", pair[0])
+		}
+		raw, ok := pair[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value type: %T", pair[1])
+		}
+		val, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			continue
+		}
+		sec, frac := math.Modf(ts)
+		offset := time.Unix(int64(sec), int64(frac*1e9)).Sub(first)
+		k := int(math.Round(float64(offset) / float64(step)))
+		if k < 0 || k >= n {
+			continue
+		}
+		if !known[k] {
+			count++
+		}
+		grid[k], known[k] = val, true
+	}
+	if count < 2 {
+		var series []float64
+		for k := range grid {
+			if known[k] {
+				series = append(series, grid[k])
+			}
+		}
+		return series, nil
+	}
+	prev := -1
+	for k := 0; k < n; k++ {
+		if !known[k] {
+			continue
+		}
+		switch {
+		case prev < 0:
+			for i := 0; i < k; i++ {
+				grid[i] = grid[k]
+			}
+		case k-prev > 1:
+			for i := prev + 1; i < k; i++ {
+				ratio := float64(i-prev) / float64(k-prev)
+				grid[i] = grid[prev] + ratio*(grid[k]-grid[prev])
+			}
+		}
+		prev = k
+	}
+	for i := prev + 1; i < n; i++ {
+		grid[i] = grid[prev]
+	}
+	return grid, nil
+}
+
+// GetNodeForecast 从当前数据源获取历史数据，按 definition.ForecastMethod 预测节点监控项在 definition.ForecastHorizon 后的值；
+// 方法为 instant 时直接返回当前值 current，历史样本不足的节点也使用当前值
+func GetNodeForecast(req string, current map[string]float64) (map[string]float64, error) {
+	if definition.ForecastMethod == definition.ForecastInstant {
+		return current, nil
+	}
+	forecaster, err := NewForecaster(definition.ForecastMethod)
+	if err != nil {
+		return nil, err
+	}
+	end := time.Now()
+	history, err := Metrics.NodeUsageRange(req, end.Add(-definition.ForecastWindow), end, definition.ForecastStep)
+	if err != nil {
+		return nil, err
+	}
+	horizon := int(math.Ceil(float64(definition.ForecastHorizon) / float64(definition.ForecastStep)))
+	predicted := make(map[string]float64)
+	for name, val := range current {
+		series := history[name]
+		if len(series) < 2 {
+			predicted[name] = val
+			continue
+		}
+		predicted[name] = math.Max(0, AggregateHorizon(forecaster.Forecast(series, horizon)))
+	}
+	return predicted, nil
+}
+
 // HttpGetNodeFreeRateMonitor 监控节点cpu和内存空闲率
 func HttpGetNodeFreeRateMonitor(req string) (map[string]float64, error) {
 	var promql string
@@ -113,14 +340,14 @@ func HttpGetNodeFreeRateMonitor(req string) (map[string]float64, error) {
 	return parseResultsToMap(results)
 }
 
-// HttpGetPodMonitor 监控pod的cpu和内存使用量
-func HttpGetPodMonitor(req, podName string) (float64, error) {
+// HttpGetPodMonitor 监控 namespace 下指定 pod 的cpu和内存使用量
+func HttpGetPodMonitor(req, namespace, podName string) (float64, error) {
 	var promql string
 	switch req {
 	case "mem":
-		promql = fmt.Sprintf(definition.PodMemURL, definition.CadvisorJob, podName)
+		promql = fmt.Sprintf(definition.PodMemURL, definition.CadvisorJob, namespace, podName)
 	case "cpu":
-		promql = fmt.Sprintf(definition.PodCpuURL, definition.CadvisorJob, podName)
+		promql = fmt.Sprintf(definition.PodCpuURL, definition.CadvisorJob, namespace, podName)
 	default:
 		return 0, errors.New("unsupported request type")
 	}
@@ -143,8 +370,33 @@ func HttpGetPodMonitor(req, podName string) (float64, error) {
 	return total, nil
 }
 
+// HttpGetPodMonitorRange 获取 namespace 下指定 pod 的cpu和内存使用量在 [start, end] 内的历史样本
+func HttpGetPodMonitorRange(req, namespace, podName string, start, end time.Time, step time.Duration) ([]float64, error) {
+	var promql string
+	switch req {
+	case "mem":
+		promql = fmt.Sprintf("sum("+definition.PodMemURL+")", definition.CadvisorJob, namespace, podName)
+	case "cpu":
+		promql = fmt.Sprintf(definition.PodCpuURL, definition.CadvisorJob, namespace, podName)
+	default:
+		return nil, errors.New("unsupported request type")
+	}
+	results, err := performRangeQuery(promql, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	var samples []float64
+	for _, item := range results {
+		series, err := parseSeries(item.Values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse series: %v", err)
+		}
+		samples = append(samples, series...)
+	}
+	return samples, nil
+}
+
 func PrintNodeMonitorToRead(req string) error {
-	monitor, _ := HttpGetNodeMonitor(req)
 	var divisor float64
 	var unit string
 	switch req {
@@ -157,6 +409,10 @@ func PrintNodeMonitorToRead(req string) error {
 	default:
 		return errors.New("unsupported request type")
 	}
+	monitor, err := GetNodeMonitor(req)
+	if err != nil {
+		return err
+	}
 	for key, val := range monitor {
 		monitor[key] = val / divisor
 	}
@@ -164,8 +420,7 @@ func PrintNodeMonitorToRead(req string) error {
 	return nil
 }
 
-func PrintPodMonitorToRead(req, podName string) error {
-	val, _ := HttpGetPodMonitor(req, podName)
+func PrintPodMonitorToRead(req, namespace, podName string) error {
 	var divisor float64
 	var unit string
 	switch req {
@@ -178,19 +433,24 @@ func PrintPodMonitorToRead(req, podName string) error {
 	default:
 		return errors.New("unsupported request type")
 	}
-	fmt.Printf("%s %s:%f(%s)\n", podName, req, val/divisor, unit)
+	val, err := Metrics.PodUsage(req, namespace, podName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s:%f(%s)\n", definition.PodKey(namespace, podName), req, val/divisor, unit)
 	return nil
 }
 
 // MonitorAndWriteResources 监控并写入资源数据
 func MonitorAndWriteResources() {
-	nodesCPU, err := HttpGetNodeMonitor("cpu")
+	snapshot := Cache.Snapshot()
+	nodesCPU, err := snapshot.NodeValues("cpu")
 	if err != nil {
 		fmt.Printf("获取CPU数据错误: %v\n", err)
 		return
 	}
 
-	nodesMem, err := HttpGetNodeMonitor("mem")
+	nodesMem, err := snapshot.NodeValues("mem")
 	if err != nil {
 		fmt.Printf("获取Mem数据错误: %v\n", err)
 		return
@@ -205,7 +465,7 @@ func MonitorAndWriteResources() {
 		memUsage[key] = value / (1 << 30)
 	}
 
-	currentTime := time.Now().Format(time.RFC3339)
+	currentTime := snapshot.Time.Format(time.RFC3339)
 	content := fmt.Sprintf("time: %s\nCPU: %v\nMem: %v\n", currentTime, cpuUsage, memUsage)
 
 	if err := writeToFile("node_resource.txt", content); err != nil {
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"strconv"
+)
+
+// ResolveCPUFactor 返回节点的 CPU 性能系数：优先使用节点注解中的基准测试结果，其次是第一条命中的策略，默认为 1
+func ResolveCPUFactor(policies []definition.CPUPerformancePolicy, n *corev1.Node) float64 {
+	if value, ok := n.Annotations[definition.NodeCPUFactorAnnotation]; ok {
+		factor, err := strconv.ParseFloat(value, 64)
+		if err == nil && factor > 0 {
+			return factor
+		}
+		fmt.Printf("节点 %s 注解 %s=%q 无效，使用配置的性能系数\n", n.Name, definition.NodeCPUFactorAnnotation, value)
+	}
+	for _, policy := range policies {
+		if policy.Factor > 0 && nodeMatches(policy.NodeSelector, n) {
+			return policy.Factor
+		}
+	}
+	return 1
+}
+
+// ResolveDiskIOPS 返回节点磁盘的 IOPS 能力：优先使用节点注解中的基准测试结果，其次是第一条命中的策略，默认为 definition.DefaultDiskIOPS
+func ResolveDiskIOPS(policies []definition.DiskIOPSPolicy, n *corev1.Node) float64 {
+	if value, ok := n.Annotations[definition.NodeDiskIOPSAnnotation]; ok {
+		iops, err := strconv.ParseFloat(value, 64)
+		if err == nil && iops > 0 {
+			return iops
+		}
+		fmt.Printf("节点 %s 注解 %s=%q 无效，使用配置的 IOPS 能力\n", n.Name, definition.NodeDiskIOPSAnnotation, value)
+	}
+	for _, policy := range policies {
+		if policy.IOPS > 0 && nodeMatches(policy.NodeSelector, n) {
+			return policy.IOPS
+		}
+	}
+	return definition.DefaultDiskIOPS
+}
+
+// IsPodPerformanceSensitive 判断 Pod 是否通过注解声明对 CPU 性能敏感
+func IsPodPerformanceSensitive(pod *corev1.Pod) bool {
+	sensitive, _ := strconv.ParseBool(pod.ObjectMeta.Annotations[definition.PodPerformanceSensitiveAnnotation])
+	return sensitive
+}
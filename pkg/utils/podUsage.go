@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ContainerUsage 容器的 CPU（毫核）和内存（字节）使用量
+type ContainerUsage struct {
+	CPU    float64
+	Memory float64
+}
+
+// PodResourceUsage Pod 的 CPU（毫核）和内存（字节）使用量及各容器明细
+type PodResourceUsage struct {
+	Namespace  string
+	Name       string
+	CPU        float64
+	Memory     float64
+	Containers map[string]*ContainerUsage
+}
+
+// PodUsageSeries Pod 的 CPU（毫核）和内存（字节）历史使用量
+type PodUsageSeries struct {
+	Namespace string
+	Name      string
+	CPU       []float64
+	Memory    []float64
+}
+
+// HttpGetAllPodsUsage 用两次查询获取所有 Pod 的 CPU 和内存使用量，按 namespace/name 索引
+func HttpGetAllPodsUsage() (map[string]*PodResourceUsage, error) {
+	usage := make(map[string]*PodResourceUsage)
+	for _, req := range []string{"cpu", "mem"} {
+		promql := definition.AllPodsCpuURL
+		if req == "mem" {
+			promql = definition.AllPodsMemURL
+		}
+		results, err := performQuery(promql)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range results {
+			namespace, name := item.Metric[definition.PodNamespaceLabel], item.Metric[definition.PodNameLabel]
+			container := item.Metric[definition.ContainerNameLabel]
+			if name == "" || len(item.Value) < 2 {
+				continue
+			}
+			raw, ok := item.Value[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected value type for %s/%s: %T", namespace, name, item.Value[1])
+			}
+			val, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse value for %s/%s: %v", namespace, name, err)
+			}
+			key := definition.PodKey(namespace, name)
+			pod, ok := usage[key]
+			if !ok {
+				pod = &PodResourceUsage{Namespace: namespace, Name: name, Containers: make(map[string]*ContainerUsage)}
+				usage[key] = pod
+			}
+			c, ok := pod.Containers[container]
+			if !ok {
+				c = &ContainerUsage{}
+				pod.Containers[container] = c
+			}
+			if req == "cpu" {
+				c.CPU += val
+				pod.CPU += val
+			} else {
+				c.Memory += val
+				pod.Memory += val
+			}
+		}
+	}
+	return usage, nil
+}
+
+// HttpGetAllPodsUsageRange 用两次区间查询获取所有 Pod 在 [start, end] 内的 CPU 和内存历史使用量，按 namespace/name 索引
+func HttpGetAllPodsUsageRange(start, end time.Time, step time.Duration) (map[string]*PodUsageSeries, error) {
+	usage := make(map[string]*PodUsageSeries)
+	for _, req := range []string{"cpu", "mem"} {
+		promql := definition.AllPodsCpuRangeURL
+		if req == "mem" {
+			promql = definition.AllPodsMemRangeURL
+		}
+		results, err := performRangeQuery(promql, start, end, step)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range results {
+			namespace, name := item.Metric[definition.PodNamespaceLabel], item.Metric[definition.PodNameLabel]
+			if name == "" {
+				continue
+			}
+			series, err := parseSeries(item.Values)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse series for %s/%s: %v", namespace, name, err)
+			}
+			key := definition.PodKey(namespace, name)
+			pod, ok := usage[key]
+			if !ok {
+				pod = &PodUsageSeries{Namespace: namespace, Name: name}
+				usage[key] = pod
+			}
+			if req == "cpu" {
+				pod.CPU = append(pod.CPU, series...)
+			} else {
+				pod.Memory = append(pod.Memory, series...)
+			}
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	corev1 "k8s.io/api/core/v1"
+	"math"
+)
+
+// ResolvePowerModel 返回第一条命中节点的功耗模型，没有命中时返回 definition.DefaultPowerModel
+func ResolvePowerModel(models []definition.PowerModel, n *corev1.Node) *definition.PowerModel {
+	for i := range models {
+		if nodeMatches(models[i].NodeSelector, n) {
+			return &models[i]
+		}
+	}
+	model := definition.DefaultPowerModel
+	return &model
+}
+
+// NodePower 按功耗模型估算节点在 CPU 使用率 u 下的功耗（瓦），u 限制在 [0, 1]
+func NodePower(model *definition.PowerModel, u float64) float64 {
+	u = math.Max(0, math.Min(1, u))
+	if len(model.Curve) < 2 {
+		return model.IdleWatts + (model.MaxWatts-model.IdleWatts)*u
+	}
+	pos := u * float64(len(model.Curve)-1)
+	i := int(math.Floor(pos))
+	if i >= len(model.Curve)-1 {
+		return model.Curve[len(model.Curve)-1]
+	}
+	return model.Curve[i] + (model.Curve[i+1]-model.Curve[i])*(pos-float64(i))
+}
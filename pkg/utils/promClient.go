@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PromError Prometheus 查询错误，包含响应中的 errorType、error 和 warnings
+type PromError struct {
+	Endpoint   string
+	StatusCode int
+	ErrorType  string
+	Message    string
+	Warnings   []string
+}
+
+func (e *PromError) Error() string {
+	msg := fmt.Sprintf("prometheus %s returned status %d", e.Endpoint, e.StatusCode)
+	if e.ErrorType != "" {
+		msg += fmt.Sprintf(", errorType=%s", e.ErrorType)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if len(e.Warnings) > 0 {
+		msg += fmt.Sprintf(" (warnings: %s)", strings.Join(e.Warnings, "; "))
+	}
+	return msg
+}
+
+// retryable 服务端错误和超时可以在同一地址重试
+func (e *PromError) retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests || e.ErrorType == "timeout"
+}
+
+// badQuery 查询本身有误（errorType 为 bad_data），所有地址都会拒绝，不再重试或切换地址
+func (e *PromError) badQuery() bool {
+	return e.ErrorType == "bad_data"
+}
+
+var (
+	promHTTPClient     *http.Client
+	promHTTPClientErr  error
+	promHTTPClientOnce sync.Once
+)
+
+// getPromHTTPClient 按 definition.Prometheus 创建共享的 HTTP 客户端
+func getPromHTTPClient() (*http.Client, error) {
+	promHTTPClientOnce.Do(func() {
+		cfg := definition.Prometheus
+		tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+		if cfg.CAFile != "" {
+			ca, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				promHTTPClientErr = fmt.Errorf("读取 Prometheus CA 证书错误: %v", err)
+				return
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				promHTTPClientErr = errors.New("Prometheus CA 证书格式错误")
+				return
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if cfg.CertFile != "" || cfg.KeyFile != "" {
+			cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+			if err != nil {
+				promHTTPClientErr = fmt.Errorf("加载 Prometheus 客户端证书错误: %v", err)
+				return
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		promHTTPClient = &http.Client{Transport: transport}
+	})
+	return promHTTPClient, promHTTPClientErr
+}
+
+// setPromAuth 按配置设置 Bearer Token 或 Basic 认证
+func setPromAuth(req *http.Request) error {
+	cfg := definition.Prometheus
+	token := cfg.BearerToken
+	if cfg.BearerTokenFile != "" {
+		data, err := os.ReadFile(cfg.BearerTokenFile)
+		if err != nil {
+			return fmt.Errorf("读取 Prometheus Token 文件错误: %v", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+	return nil
+}
+
+// retryDelay 第 attempt 次重试前的等待时间：基础退避按 2^attempt 增长，并叠加 [0, 基础退避) 的随机抖动
+func retryDelay(attempt int) time.Duration {
+	backoff := definition.Prometheus.RetryBackoff
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff<<attempt + time.Duration(rand.Int63n(int64(backoff)))
+}
+
+// doPromRequest 调用 Prometheus HTTP API（/api/v1/<api>），失败时按配置重试并依次切换地址；
+// 认证失败、404 等其他客户端错误视为该地址配置有误，不重试，直接切换到下一个地址
+func doPromRequest(api string, params url.Values) ([]MetricResult, error) {
+	client, err := getPromHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	endpoints := definition.Prometheus.Endpoints
+	if len(endpoints) == 0 {
+		return nil, errors.New("未配置 Prometheus 地址")
+	}
+	var lastErr error
+	for _, endpoint := range endpoints {
+		for attempt := 0; attempt <= definition.Prometheus.Retries; attempt++ {
+			if attempt > 0 {
+				time.Sleep(retryDelay(attempt - 1))
+			}
+			results, err := promRequestOnce(client, endpoint, api, params)
+			if err == nil {
+				return results, nil
+			}
+			lastErr = err
+			var promErr *PromError
+			if errors.As(err, &promErr) {
+				if promErr.badQuery() {
+					return nil, err
+				}
+				if !promErr.retryable() {
+					break
+				}
+			}
+		}
+		fmt.Printf("Prometheus %s 不可用，尝试下一个地址: %v\n", endpoint, lastErr)
+	}
+	return nil, lastErr
+}
+
+// promRequestOnce 向单个地址发送一次带超时的查询
+func promRequestOnce(client *http.Client, endpoint, api string, params url.Values) ([]MetricResult, error) {
+	ctx := context.Background()
+	if definition.Prometheus.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, definition.Prometheus.Timeout)
+		defer cancel()
+	}
+	fullURL := fmt.Sprintf("%s/api/v1/%s?%s", strings.TrimSuffix(endpoint, "/"), api, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %v", err)
+	}
+	if err := setPromAuth(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request to %s failed: %v", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %v", endpoint, err)
+	}
+
+	var qr queryResponse
+	if err := json.Unmarshal(body, &qr); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, &PromError{Endpoint: endpoint, StatusCode: resp.StatusCode, Message: string(body)}
+		}
+		return nil, fmt.Errorf("failed to parse JSON from %s: %v", endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK || qr.Status != "success" {
+		return nil, &PromError{
+			Endpoint:   endpoint,
+			StatusCode: resp.StatusCode,
+			ErrorType:  qr.ErrorType,
+			Message:    qr.Error,
+			Warnings:   qr.Warnings,
+		}
+	}
+	if len(qr.Warnings) > 0 {
+		fmt.Printf("Prometheus %s 查询警告: %s\n", endpoint, strings.Join(qr.Warnings, "; "))
+	}
+	return qr.Data.Result, nil
+}
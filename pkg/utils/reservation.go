@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"strconv"
+	"strings"
+)
+
+// nodeMatches 判断节点是否被策略选中：名称命中，或标签全部满足
+func nodeMatches(selector definition.NodeSelector, n *corev1.Node) bool {
+	if Contains(selector.NodeNames, n.Name) {
+		return true
+	}
+	if len(selector.NodeLabels) == 0 {
+		return false
+	}
+	for key, want := range selector.NodeLabels {
+		got, ok := n.Labels[key]
+		if !ok || (want != "" && got != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseReserve 解析预留量，支持百分比（相对 capacity）或绝对值（由 parse 转换）
+func parseReserve(value string, capacity float64, parse func(string) (float64, error)) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, fmt.Errorf("不支持的百分比: %s", value)
+		}
+		return capacity * percent / 100, nil
+	}
+	return parse(value)
+}
+
+// ResolveReservation 计算所有命中策略对节点的预留量，每种资源取最大值
+func ResolveReservation(policies []definition.ReservationPolicy, n *corev1.Node, node *definition.Node) (*definition.Reservation, error) {
+	r := &definition.Reservation{}
+	for _, policy := range policies {
+		if !nodeMatches(policy.NodeSelector, n) {
+			continue
+		}
+		cpu, err := parseReserve(policy.CPU, node.CapacityCPU, cpuConvertToMilliValue)
+		if err != nil {
+			return nil, fmt.Errorf("预留策略 %s 的 CPU 配置错误: %v", policy.Name, err)
+		}
+		mem, err := parseReserve(policy.Memory, node.CapacityMemory, memConvertToInt)
+		if err != nil {
+			return nil, fmt.Errorf("预留策略 %s 的内存配置错误: %v", policy.Name, err)
+		}
+		pods, err := parseReserve(policy.Pods, node.CapacityPods, countConvertToInt)
+		if err != nil {
+			return nil, fmt.Errorf("预留策略 %s 的 Pod 数配置错误: %v", policy.Name, err)
+		}
+		r.CPU = max(r.CPU, cpu)
+		r.Memory = max(r.Memory, mem)
+		r.Pods = max(r.Pods, pods)
+		r.Policies = append(r.Policies, policy.Name)
+	}
+	return r, nil
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"fmt"
+	"time"
+)
+
+// MetricsSource 节点与 Pod 使用量的数据源。req 取值 "cpu"（毫核）、"mem"（字节），
+// Prometheus 还支持 "storage"、"net"（网卡利用率 0~1）、"net-speed" 等节点监控项
+type MetricsSource interface {
+	// Name 数据源名称
+	Name() string
+	// NodeUsage 返回每个节点的当前使用量及样本时间戳
+	NodeUsage(req string) (map[string]NodeSample, error)
+	// NodeUsageRange 返回每个节点在 [start, end] 内按 step 采样的历史使用量
+	NodeUsageRange(req string, start, end time.Time, step time.Duration) (map[string][]float64, error)
+	// NodeLastScrape 返回每个节点最近一次采集的时间，数据源无此信息时返回 nil
+	NodeLastScrape() (map[string]time.Time, error)
+	// PodUsage 返回 namespace 下指定 Pod 的当前使用量
+	PodUsage(req, namespace, podName string) (float64, error)
+	// PodUsageRange 返回 namespace 下指定 Pod 在 [start, end] 内按 step 采样的历史使用量
+	PodUsageRange(req, namespace, podName string, start, end time.Time, step time.Duration) ([]float64, error)
+	// AllPodsUsage 批量返回所有 Pod 的当前使用量，按 namespace/name 索引
+	AllPodsUsage() (map[string]*PodResourceUsage, error)
+	// AllPodsUsageRange 批量返回所有 Pod 在 [start, end] 内的历史使用量，按 namespace/name 索引
+	AllPodsUsageRange(start, end time.Time, step time.Duration) (map[string]*PodUsageSeries, error)
+}
+
+// Metrics 当前使用的数据源，由 InitMetricsSource 按 definition.MetricsBackend 设置
+var Metrics MetricsSource = &PrometheusSource{}
+
+// InitMetricsSource 按 definition.MetricsBackend 创建数据源
+func InitMetricsSource() error {
+	switch definition.MetricsBackend {
+	case definition.MetricsBackendPrometheus:
+		Metrics = &PrometheusSource{}
+	case definition.MetricsBackendMetricsServer:
+		Metrics = &MetricsServerSource{}
+	case definition.MetricsBackendStatic:
+		source, err := NewStaticSource(definition.StaticMetricsFile)
+		if err != nil {
+			return err
+		}
+		Metrics = source
+	default:
+		return fmt.Errorf("不支持的监控数据源: %s", definition.MetricsBackend)
+	}
+	return nil
+}
+
+// GetNodeMonitor 从缓存的监控快照获取节点监控项的当前值
+func GetNodeMonitor(req string) (map[string]float64, error) {
+	return Cache.Snapshot().NodeValues(req)
+}
+
+// PrometheusSource 基于 Prometheus（node-exporter + cAdvisor）的数据源
+type PrometheusSource struct{}
+
+func (s *PrometheusSource) Name() string {
+	return definition.MetricsBackendPrometheus
+}
+
+func (s *PrometheusSource) NodeUsage(req string) (map[string]NodeSample, error) {
+	return HttpGetNodeSamples(req)
+}
+
+func (s *PrometheusSource) NodeUsageRange(req string, start, end time.Time, step time.Duration) (map[string][]float64, error) {
+	return HttpGetNodeMonitorRange(req, start, end, step)
+}
+
+func (s *PrometheusSource) NodeLastScrape() (map[string]time.Time, error) {
+	return HttpGetNodeLastScrape()
+}
+
+func (s *PrometheusSource) PodUsage(req, namespace, podName string) (float64, error) {
+	return HttpGetPodMonitor(req, namespace, podName)
+}
+
+func (s *PrometheusSource) PodUsageRange(req, namespace, podName string, start, end time.Time, step time.Duration) ([]float64, error) {
+	return HttpGetPodMonitorRange(req, namespace, podName, start, end, step)
+}
+
+func (s *PrometheusSource) AllPodsUsage() (map[string]*PodResourceUsage, error) {
+	return HttpGetAllPodsUsage()
+}
+
+func (s *PrometheusSource) AllPodsUsageRange(start, end time.Time, step time.Duration) (map[string]*PodUsageSeries, error) {
+	return HttpGetAllPodsUsageRange(start, end, step)
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// metricsUsage metrics.k8s.io 中的资源用量
+type metricsUsage struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+}
+
+// nodeMetricsList metrics.k8s.io/v1beta1 NodeMetricsList
+type nodeMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Timestamp time.Time    `json:"timestamp"`
+		Usage     metricsUsage `json:"usage"`
+	} `json:"items"`
+}
+
+// podMetricsList metrics.k8s.io/v1beta1 PodMetricsList
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Timestamp  time.Time `json:"timestamp"`
+		Containers []struct {
+			Name  string       `json:"name"`
+			Usage metricsUsage `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// MetricsServerSource 基于 Kubernetes metrics.k8s.io API（metrics-server）的数据源，只提供 cpu、mem 的当前值
+type MetricsServerSource struct{}
+
+func (s *MetricsServerSource) Name() string {
+	return definition.MetricsBackendMetricsServer
+}
+
+// getMetrics 调用 metrics.k8s.io API 并解析 JSON
+func (s *MetricsServerSource) getMetrics(path string, out interface{}) error {
+	data, err := definition.ClientSet.Discovery().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/" + path).
+		DoRaw(context.TODO())
+	if err != nil {
+		return fmt.Errorf("请求 metrics.k8s.io 失败: %v", err)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// usageValue 将 metrics.k8s.io 的用量转换为毫核或字节
+func usageValue(req string, usage metricsUsage) (float64, error) {
+	switch req {
+	case "cpu":
+		return cpuConvertToMilliValue(usage.CPU)
+	case "mem":
+		return memConvertToInt(usage.Memory)
+	default:
+		return 0, fmt.Errorf("metrics-server 不支持监控项: %s", req)
+	}
+}
+
+func (s *MetricsServerSource) NodeUsage(req string) (map[string]NodeSample, error) {
+	var list nodeMetricsList
+	if err := s.getMetrics("nodes", &list); err != nil {
+		return nil, err
+	}
+	nodeSamples := make(map[string]NodeSample)
+	for _, item := range list.Items {
+		val, err := usageValue(req, item.Usage)
+		if err != nil {
+			return nil, err
+		}
+		nodeSamples[item.Metadata.Name] = NodeSample{Value: val, Timestamp: item.Timestamp}
+	}
+	return nodeSamples, nil
+}
+
+func (s *MetricsServerSource) NodeUsageRange(req string, start, end time.Time, step time.Duration) (map[string][]float64, error) {
+	return nil, errors.New("metrics-server 不支持历史数据查询")
+}
+
+// NodeLastScrape metrics-server 的样本时间戳即采集时间，由 NodeUsage 返回，这里不再单独提供
+func (s *MetricsServerSource) NodeLastScrape() (map[string]time.Time, error) {
+	return nil, nil
+}
+
+func (s *MetricsServerSource) PodUsage(req, namespace, podName string) (float64, error) {
+	var list podMetricsList
+	if err := s.getMetrics("pods", &list); err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, item := range list.Items {
+		if item.Metadata.Namespace != namespace || item.Metadata.Name != podName {
+			continue
+		}
+		for _, container := range item.Containers {
+			val, err := usageValue(req, container.Usage)
+			if err != nil {
+				return 0, err
+			}
+			total += val
+		}
+	}
+	return total, nil
+}
+
+func (s *MetricsServerSource) PodUsageRange(req, namespace, podName string, start, end time.Time, step time.Duration) ([]float64, error) {
+	return nil, errors.New("metrics-server 不支持历史数据查询")
+}
+
+func (s *MetricsServerSource) AllPodsUsage() (map[string]*PodResourceUsage, error) {
+	var list podMetricsList
+	if err := s.getMetrics("pods", &list); err != nil {
+		return nil, err
+	}
+	usage := make(map[string]*PodResourceUsage)
+	for _, item := range list.Items {
+		pod := &PodResourceUsage{
+			Namespace:  item.Metadata.Namespace,
+			Name:       item.Metadata.Name,
+			Containers: make(map[string]*ContainerUsage),
+		}
+		for _, container := range item.Containers {
+			cpu, err := usageValue("cpu", container.Usage)
+			if err != nil {
+				return nil, err
+			}
+			mem, err := usageValue("mem", container.Usage)
+			if err != nil {
+				return nil, err
+			}
+			pod.Containers[container.Name] = &ContainerUsage{CPU: cpu, Memory: mem}
+			pod.CPU += cpu
+			pod.Memory += mem
+		}
+		usage[definition.PodKey(pod.Namespace, pod.Name)] = pod
+	}
+	return usage, nil
+}
+
+func (s *MetricsServerSource) AllPodsUsageRange(start, end time.Time, step time.Duration) (map[string]*PodUsageSeries, error) {
+	return nil, errors.New("metrics-server 不支持历史数据查询")
+}
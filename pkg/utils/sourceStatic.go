@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// staticMetrics 静态数据文件格式，值的单位与 Prometheus 数据源一致（cpu 毫核，mem 字节）
+//
+//	{
+//	  "nodes": {"node1": {"cpu": 1500, "mem": 8589934592}},
+//	  "nodeRanges": {"node1": {"cpu": [1400, 1500, 1600]}},
+//	  "pods": {"k8s/demo1": {"cpu": 800, "mem": 524288000}},
+//	  "podRanges": {"k8s/demo1": {"cpu": [700, 800]}}
+//	}
+//
+// Pod 以 namespace/name 为键，也可以只写 name（此时命名空间为空）
+type staticMetrics struct {
+	Nodes      map[string]map[string]float64   `json:"nodes"`
+	NodeRanges map[string]map[string][]float64 `json:"nodeRanges"`
+	Pods       map[string]map[string]float64   `json:"pods"`
+	PodRanges  map[string]map[string][]float64 `json:"podRanges"`
+}
+
+// StaticSource 从 JSON 文件读取固定数据的数据源，用于测试和演示；样本时间戳始终为当前时间
+type StaticSource struct {
+	data staticMetrics
+}
+
+// NewStaticSource 从文件加载静态数据
+func NewStaticSource(filename string) (*StaticSource, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取静态监控数据文件错误: %v", err)
+	}
+	s := &StaticSource{}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("解析静态监控数据文件错误: %v", err)
+	}
+	s.data.Pods = normalizePodKeys(s.data.Pods)
+	s.data.PodRanges = normalizePodKeys(s.data.PodRanges)
+	return s, nil
+}
+
+// normalizePodKeys 将只写 name 的 Pod 键统一为 definition.PodKey 格式，便于按 namespace/name 查找
+func normalizePodKeys[V any](pods map[string]V) map[string]V {
+	normalized := make(map[string]V, len(pods))
+	for key, value := range pods {
+		normalized[definition.PodKey(definition.SplitPodKey(key))] = value
+	}
+	return normalized
+}
+
+func (s *StaticSource) Name() string {
+	return definition.MetricsBackendStatic
+}
+
+func (s *StaticSource) NodeUsage(req string) (map[string]NodeSample, error) {
+	now := time.Now()
+	nodeSamples := make(map[string]NodeSample)
+	for name, values := range s.data.Nodes {
+		if val, ok := values[req]; ok {
+			nodeSamples[name] = NodeSample{Value: val, Timestamp: now}
+		}
+	}
+	return nodeSamples, nil
+}
+
+func (s *StaticSource) NodeUsageRange(req string, start, end time.Time, step time.Duration) (map[string][]float64, error) {
+	nodeSeries := make(map[string][]float64)
+	for name, ranges := range s.data.NodeRanges {
+		if series, ok := ranges[req]; ok {
+			nodeSeries[name] = series
+		}
+	}
+	return nodeSeries, nil
+}
+
+func (s *StaticSource) NodeLastScrape() (map[string]time.Time, error) {
+	return nil, nil
+}
+
+func (s *StaticSource) PodUsage(req, namespace, podName string) (float64, error) {
+	return s.data.Pods[definition.PodKey(namespace, podName)][req], nil
+}
+
+func (s *StaticSource) PodUsageRange(req, namespace, podName string, start, end time.Time, step time.Duration) ([]float64, error) {
+	return s.data.PodRanges[definition.PodKey(namespace, podName)][req], nil
+}
+
+func (s *StaticSource) AllPodsUsage() (map[string]*PodResourceUsage, error) {
+	usage := make(map[string]*PodResourceUsage)
+	for key, values := range s.data.Pods {
+		namespace, name := definition.SplitPodKey(key)
+		usage[definition.PodKey(namespace, name)] = &PodResourceUsage{
+			Namespace:  namespace,
+			Name:       name,
+			CPU:        values["cpu"],
+			Memory:     values["mem"],
+			Containers: map[string]*ContainerUsage{},
+		}
+	}
+	return usage, nil
+}
+
+func (s *StaticSource) AllPodsUsageRange(start, end time.Time, step time.Duration) (map[string]*PodUsageSeries, error) {
+	usage := make(map[string]*PodUsageSeries)
+	for key, ranges := range s.data.PodRanges {
+		namespace, name := definition.SplitPodKey(key)
+		usage[definition.PodKey(namespace, name)] = &PodUsageSeries{
+			Namespace: namespace,
+			Name:      name,
+			CPU:       ranges["cpu"],
+			Memory:    ranges["mem"],
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	corev1 "k8s.io/api/core/v1"
+	"strconv"
+)
+
+// NodeTier 返回节点所属的层级，不属于任何层级时返回空字符串
+func NodeTier(name string) string {
+	for _, tier := range definition.Tiers {
+		if Contains(tier.NodeNames, name) {
+			return tier.Name
+		}
+	}
+	return ""
+}
+
+// InAnyTier 判断节点是否属于某一层级，即是否参与调度
+func InAnyTier(name string) bool {
+	return NodeTier(name) != ""
+}
+
+// GetPodTierRequest 解析 Pod 的分层调度注解
+func GetPodTierRequest(pod *corev1.Pod) definition.TierRequest {
+	annotations := pod.ObjectMeta.Annotations
+	req := definition.TierRequest{
+		Tier:     annotations[definition.PodTierAnnotation],
+		EdgeSite: annotations[definition.PodEdgeSiteAnnotation],
+		Fallback: annotations[definition.PodTierFallbackAnnotation],
+	}
+	req.LatencySensitive, _ = strconv.ParseBool(annotations[definition.PodLatencySensitiveAnnotation])
+	req.ComputeHeavy, _ = strconv.ParseBool(annotations[definition.PodComputeHeavyAnnotation])
+	if req.Fallback == "" {
+		req.Fallback = definition.DefaultTierFallback
+	}
+	return req
+}
@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"MBCTG/pkg/definition"
+	"context"
+	"encoding/json"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GetPodWorkload 根据 owner reference 返回 Pod 所属工作负载的标识 namespace/Kind/name，独立 Pod 返回空字符串
+// ReplicaSet 通过 pod-template-hash 标签还原为 Deployment，由 CronJob 创建的 Job 归到 CronJob
+func GetPodWorkload(pod *corev1.Pod) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return ""
+	}
+	kind, name := owner.Kind, owner.Name
+	switch owner.Kind {
+	case "ReplicaSet":
+		if hash, ok := pod.Labels["pod-template-hash"]; ok && strings.HasSuffix(name, "-"+hash) {
+			kind, name = "Deployment", strings.TrimSuffix(name, "-"+hash)
+		}
+	case "Job":
+		if definition.ClientSet != nil {
+			job, err := definition.ClientSet.BatchV1().Jobs(pod.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+			if err == nil {
+				if jobOwner := metav1.GetControllerOf(job); jobOwner != nil && jobOwner.Kind == "CronJob" {
+					kind, name = jobOwner.Kind, jobOwner.Name
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("%s/%s/%s", pod.Namespace, kind, name)
+}
+
+// podOwner Pod 所属的工作负载及最近一次看到该 Pod 的时间
+type podOwner struct {
+	workload string
+	seen     time.Time
+}
+
+// WorkloadProfiler 汇总 cAdvisor 历史用量，按工作负载维护 p50/p95 画像
+type WorkloadProfiler struct {
+	mu       sync.RWMutex
+	profiles map[string]*definition.WorkloadProfile
+	// owners 按 namespace/name 记录 Pod 所属的工作负载，Pod 结束或被删除后仍保留 definition.ProfileWindow，
+	// 使已完成的 Job、滚动更新替换掉的 Pod 的历史用量也能计入画像
+	owners map[string]podOwner
+}
+
+// NewWorkloadProfiler 创建画像器，并从 definition.ProfileFile 加载已保存的画像
+func NewWorkloadProfiler() *WorkloadProfiler {
+	p := &WorkloadProfiler{
+		profiles: make(map[string]*definition.WorkloadProfile),
+		owners:   make(map[string]podOwner),
+	}
+	if err := p.load(); err != nil {
+		fmt.Printf("加载工作负载画像错误: %v\n", err)
+	}
+	return p
+}
+
+// Get 返回工作负载的画像，不存在或样本不足时返回 false
+func (p *WorkloadProfiler) Get(workload string) (*definition.WorkloadProfile, bool) {
+	if workload == "" {
+		return nil, false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	profile, ok := p.profiles[workload]
+	if !ok || profile.Samples < definition.ProfileMinSamples {
+		return nil, false
+	}
+	return profile, true
+}
+
+// Observe 记录 Pod 所属的工作负载，由 Pod 的 watch 事件（包括删除事件）调用
+func (p *WorkloadProfiler) Observe(pod *corev1.Pod) {
+	key := definition.PodKey(pod.Namespace, pod.Name)
+	now := time.Now()
+	p.mu.Lock()
+	owner, ok := p.owners[key]
+	p.mu.Unlock()
+	if !ok {
+		// owner 在 Pod 的生命周期内不变，只在第一次看到时解析（Job 需要查询 API）
+		owner.workload = GetPodWorkload(pod)
+	}
+	owner.seen = now
+	p.mu.Lock()
+	p.owners[key] = owner
+	p.mu.Unlock()
+}
+
+// workloadOf 返回 namespace/name 对应 Pod 所属的工作负载，未记录或独立 Pod 返回空字符串
+func (p *WorkloadProfiler) workloadOf(key string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.owners[key].workload
+}
+
+// pruneOwners 删除超过 definition.ProfileWindow 没有再看到的 Pod，它们已不在历史窗口内
+func (p *WorkloadProfiler) pruneOwners(before time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, owner := range p.owners {
+		if owner.seen.Before(before) {
+			delete(p.owners, key)
+		}
+	}
+}
+
+// Refresh 拉取 definition.ProfileWindow 内所有 Pod 的 CPU、内存用量，按工作负载汇总为画像；
+// 已结束或已删除的 Pod 通过 Observe 记录的归属计入，不要求 Pod 仍在运行
+func (p *WorkloadProfiler) Refresh() error {
+	podsList, err := definition.ClientSet.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range podsList.Items {
+		p.Observe(&podsList.Items[i])
+	}
+	end := time.Now()
+	start := end.Add(-definition.ProfileWindow)
+	p.pruneOwners(start)
+	// 一次批量查询所有 Pod 的历史用量，按 namespace/name 与工作负载对应
+	history, err := Metrics.AllPodsUsageRange(start, end, definition.ProfileStep)
+	if err != nil {
+		return fmt.Errorf("获取 Pod 历史用量错误: %v", err)
+	}
+	cpuSamples := make(map[string][]float64)
+	memSamples := make(map[string][]float64)
+	for key, series := range history {
+		workload := p.workloadOf(key)
+		if workload == "" {
+			continue
+		}
+		cpuSamples[workload] = append(cpuSamples[workload], series.CPU...)
+		memSamples[workload] = append(memSamples[workload], series.Memory...)
+	}
+
+	profiles := make(map[string]*definition.WorkloadProfile)
+	for workload, cpu := range cpuSamples {
+		mem := memSamples[workload]
+		if len(cpu) == 0 || len(mem) == 0 {
+			continue
+		}
+		profiles[workload] = &definition.WorkloadProfile{
+			Workload:  workload,
+			CPUP50:    percentile(cpu, 50),
+			CPUP95:    percentile(cpu, 95),
+			MemP50:    percentile(mem, 50),
+			MemP95:    percentile(mem, 95),
+			Samples:   min(len(cpu), len(mem)),
+			UpdatedAt: end,
+		}
+	}
+
+	p.mu.Lock()
+	// 保留本轮没有运行中 Pod 的工作负载的旧画像
+	for workload, profile := range profiles {
+		p.profiles[workload] = profile
+	}
+	p.mu.Unlock()
+	return p.save()
+}
+
+// load 从 definition.ProfileFile 加载画像
+func (p *WorkloadProfiler) load() error {
+	if definition.ProfileFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(definition.ProfileFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var profiles map[string]*definition.WorkloadProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for workload, profile := range profiles {
+		p.profiles[workload] = profile
+	}
+	return nil
+}
+
+// save 将画像写入 definition.ProfileFile
+func (p *WorkloadProfiler) save() error {
+	if definition.ProfileFile == "" {
+		return nil
+	}
+	p.mu.RLock()
+	data, err := json.MarshalIndent(p.profiles, "", "  ")
+	p.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(definition.ProfileFile, data, 0644)
+}
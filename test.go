@@ -7,7 +7,7 @@ import (
 
 func main() {
 	promUrl := definition.PodCpuURL
-	podName := "demo1"
-	promUrl = fmt.Sprintf(promUrl, definition.CadvisorJob, podName)
+	namespace, podName := "default", "demo1"
+	promUrl = fmt.Sprintf(promUrl, definition.CadvisorJob, namespace, podName)
 	fmt.Println(promUrl)
 }
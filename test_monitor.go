@@ -7,6 +7,6 @@ import (
 func main() {
 	_ = utils.PrintNodeMonitorToRead("cpu")
 	_ = utils.PrintNodeMonitorToRead("mem")
-	_ = utils.PrintPodMonitorToRead("cpu", "demo1")
-	_ = utils.PrintPodMonitorToRead("mem", "demo1")
+	_ = utils.PrintPodMonitorToRead("cpu", "default", "demo1")
+	_ = utils.PrintPodMonitorToRead("mem", "default", "demo1")
 }